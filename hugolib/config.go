@@ -111,5 +111,28 @@ func loadDefaultSettingsFor(v *viper.Viper) {
 	v.SetDefault("defaultContentLanguage", "en")
 	v.SetDefault("defaultContentLanguageInSubdir", false)
 	v.SetDefault("enableMissingTranslationPlaceholders", false)
+	v.SetDefault("missingTranslationBehavior", "")
+	v.SetDefault("failOnMissingI18nLanguage", false)
+	v.SetDefault("translationFlags", []string{})
+	v.SetDefault("missingTranslationsFile", "")
+	v.SetDefault("enabledLanguages", []string{})
+	v.SetDefault("wrapFallbackTranslations", false)
+	v.SetDefault("maxTranslationArgLength", 0)
+	v.SetDefault("htmlTranslationTags", []string{})
+	v.SetDefault("htmlTranslationAttributes", []string{})
+	v.SetDefault("disallowedTranslationHTML", "error")
+	v.SetDefault("disableTranslationNumberFormatting", false)
+	v.SetDefault("translationNewlines", "keep")
+	v.SetDefault("translationFallbacks", map[string]interface{}{})
+	v.SetDefault("maxFallbackDepth", 0)
+	v.SetDefault("translationEnvironment", "")
+	v.SetDefault("recordTemplateFuncUsage", false)
+	v.SetDefault("pluralCountAllowlist", []string{})
+	v.SetDefault("translationCountField", "Count")
+	v.SetDefault("paddedTranslationIDs", "trim")
+	v.SetDefault("formattingFallbackLocale", "")
+	v.SetDefault("localizeQuotes", false)
+	v.SetDefault("collectTemplateErrors", false)
+	v.SetDefault("languageNeutralKeys", []string{})
 	v.SetDefault("enableGitInfo", false)
 }
@@ -0,0 +1,177 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const ellipsis = "…"
+
+// truncateValue shortens v, a value inserted into translationID, if it is
+// a string longer than maxTranslationArgLength runes, so a whole page of
+// text passed to a translation by mistake does not end up in the output.
+// Strings anywhere in the args are covered, e.g. the fields of a struct,
+// and the args themselves are never modified.
+func (t *Translator) truncateValue(translationID string, v interface{}) interface{} {
+	max := t.cfg.GetInt("maxTranslationArgLength")
+	rv := reflect.ValueOf(v)
+	// Templates print what pointers point to.
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if max <= 0 || rv.Kind() != reflect.String {
+		return v
+	}
+
+	s := rv.String()
+	if utf8.RuneCountInString(s) <= max {
+		return v
+	}
+	t.logger.WARN.Printf("Argument to translation %q truncated to %d characters", translationID, max)
+	return reflect.ValueOf(truncateString(s, max)).Convert(rv.Type()).Interface()
+}
+
+// countArgs returns args with the count taken from the data, if that is the
+// only arg, so themes do not need to pass it twice. The count is the entry or
+// field of the data named by translationCountField, if it is an integer.
+func (t *Translator) countArgs(args []interface{}) []interface{} {
+	field := t.cfg.GetString("translationCountField")
+	// go-i18n already takes the count from the Count entry or field.
+	if len(args) != 1 || isNumber(args[0]) || field == "" || field == "Count" {
+		return args
+	}
+
+	count, found := fieldValue(args[0], field)
+	if !found {
+		return args
+	}
+	switch count.(type) {
+	case int, int8, int16, int32, int64:
+	default:
+		return args
+	}
+
+	data := args[0]
+	if m, ok := data.(map[string]interface{}); ok {
+		// go-i18n sets the Count entry of the map it is given.
+		c := make(map[string]interface{}, len(m)+1)
+		for k, v := range m {
+			c[k] = v
+		}
+		data = c
+	}
+	return []interface{}{count, data}
+}
+
+// truncateString shortens s to max runes, the last being an ellipsis.
+func truncateString(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	n := 0
+	for i := range s {
+		if n == max-1 {
+			return s[:i] + ellipsis
+		}
+		n++
+	}
+	return s
+}
+
+// formatArg formats v, inserted into a translation in lang, if it is a
+// number, e.g. 1000 as "1,000" in English. Floats keep all their decimals.
+// Values with a String method, e.g. a time.Duration, are left to it, and so
+// are numbers in languages the default Formatter has no locale data for,
+// unless formattingFallbackLocale is set, and values piped to the raw func.
+// Other Formatters are passed integers as a float64, which is exact up to
+// 2^53.
+func (t *Translator) formatArg(lang string, v interface{}) (string, bool) {
+	if _, ok := v.(fmt.Stringer); ok {
+		return "", false
+	}
+
+	s, ok := decimalString(v)
+	if !ok {
+		return "", false
+	}
+
+	f := t.getFormatter()
+	if df, ok := f.(defaultFormatter); ok {
+		l, found := df.locales.lookup(lang)
+		if !found {
+			return "", false
+		}
+		return formatDecimal(l, s), true
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return "", false
+	}
+	decimals := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		decimals = len(s) - i - 1
+	}
+	return f.FormatNumber(lang, n, decimals), true
+}
+
+// decimalString returns v as a decimal number formatted by strconv, if it
+// is a finite number, e.g. "1000" and "0.1" for a float32.
+func decimalString(v interface{}) (string, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", false
+		}
+		bitSize := 64
+		if rv.Kind() == reflect.Float32 {
+			bitSize = 32
+		}
+		return strconv.FormatFloat(f, 'f', -1, bitSize), true
+	}
+	return "", false
+}
+
+// rawValue is a value inserted into a translation as is, without number
+// formatting, as returned by the raw template func, e.g. for a year or an
+// id in a URL.
+type rawValue string
+
+// raw returns v as a rawValue. Numbers are written like strconv does, so
+// 2017 is "2017" in every language.
+func raw(v interface{}) rawValue {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	if s, ok := decimalString(rv.Interface()); ok {
+		return rawValue(s)
+	}
+	return rawValue(fmt.Sprint(rv.Interface()))
+}
@@ -0,0 +1,60 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateArgs(t *testing.T) {
+	var buf bytes.Buffer
+	v := newTestConfig()
+	v.Set("maxTranslationArgLength", 10)
+
+	tr := newTranslator(newTestTranslator().bundle, v, jww.NewNotepad(jww.LevelWarn, jww.LevelError, &buf, ioutil.Discard, "", 0))
+	require.NoError(t, tr.addTranslationFile("en.yaml", []byte(`- id: "quote"
+  translation: "“{{ .Text }}” by {{ .Author }}"
+`)))
+	tr.initFuncs()
+
+	data := map[string]interface{}{
+		"Text":   template.HTML(strings.Repeat("ü", 1000)),
+		"Author": "Ann",
+	}
+	require.Equal(t, "“üüüüüüüüü…” by Ann", tr.Func("en")("quote", data))
+	require.Equal(t, template.HTML(strings.Repeat("ü", 1000)), data["Text"], "the args must not be modified")
+	require.Contains(t, buf.String(), `Argument to translation "quote" truncated to 10 characters`)
+
+	type quote struct {
+		Text   string
+		Author *string
+	}
+	author := strings.Repeat("a", 20)
+	require.Equal(t, "“aaaaaaaaa…” by aaaaaaaaa…", tr.Func("en")("quote", &quote{Text: author, Author: &author}))
+	require.Equal(t, "“Short” by aaaaaaaaa…", tr.Func("en")("quote", quote{Text: "Short", Author: &author}))
+
+	buf.Reset()
+	require.Equal(t, "“Short” by Ann", tr.Func("en")("quote", map[string]interface{}{"Text": "Short", "Author": "Ann"}))
+	require.Empty(t, buf.String())
+
+	require.Equal(t, "abc", truncateString("abc", 3))
+	require.Equal(t, "ab…", truncateString("abcd", 3))
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+	"time"
+)
+
+// BenchmarkAll renders every translation once, in every language it is
+// defined in, and returns the time it took by id, summed over the
+// languages, e.g. to check a performance budget before shipping or to find
+// a pathologically slow translation. The translations are rendered with a
+// count of 2 and sample data with the fields from Variables, so the time
+// includes parsing their templates, if not rendered before. Errors are
+// neither logged nor collected, and template func usage is not recorded.
+func (t *Translator) BenchmarkAll() map[string]time.Duration {
+	t.mu.RLock()
+	ids := definedIDs(t.bundle.Translations(), t.icuMessages, t.bucketMessages)
+	t.mu.RUnlock()
+
+	args := make(map[string][]interface{})
+	costs := make(map[string]time.Duration)
+	for lang, m := range ids {
+		for id := range m {
+			if _, found := args[id]; !found {
+				args[id] = sampleArgs(t.Variables(id))
+			}
+
+			start := time.Now()
+			t.lookup(lang, id, resolveOptions{quiet: true}, args[id]...)
+			costs[id] += time.Since(start)
+		}
+	}
+	return costs
+}
+
+// sampleArgs returns translate func args with a count of 2 and data with
+// the given fields, e.g. "Page.Title", set to "sample".
+func sampleArgs(fields []string) []interface{} {
+	data := make(map[string]interface{})
+	for _, field := range fields {
+		if field == "Count" || field == "count" {
+			continue
+		}
+
+		names := strings.Split(field, ".")
+		m := data
+		for _, name := range names[:len(names)-1] {
+			sub, ok := m[name].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				m[name] = sub
+			}
+			m = sub
+		}
+		if _, found := m[names[len(names)-1]]; !found {
+			m[names[len(names)-1]] = "sample"
+		}
+	}
+	return []interface{}{2, data}
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchmarkAll(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello, {{ .Page.Author.Name }}, on {{ .Page.Title }}!"
+- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "messages"
+  format: icu
+  translation: "{gender, select, female{She has} other{They have}} {count, plural, other{# messages}}"
+`},
+		testFile{"de.yaml", `- id: "hello"
+  translation: "Hallo, {{ .Page.Author.Name }}!"
+- id: "bye"
+  translation: "Tschüss"
+`},
+		testFile{"it.json", `[{"id": "items", "format": "buckets", "translation": {"0": "Nessuno", "1+": "{{ .Count }}"}}]`},
+	)
+
+	costs := tr.BenchmarkAll()
+
+	require.Len(t, costs, 5)
+	for _, id := range []string{"hello", "files", "messages", "bye", "items"} {
+		require.Contains(t, costs, id)
+	}
+
+	require.Equal(t, []interface{}{2, map[string]interface{}{
+		"Page":   map[string]interface{}{"Title": "sample", "Author": map[string]interface{}{"Name": "sample"}},
+		"gender": "sample",
+	}}, sampleArgs([]string{"Count", "Page", "Page.Author.Name", "Page.Title", "count", "gender"}))
+}
+
+func TestBenchmarkAllHasNoDiagnostics(t *testing.T) {
+	v := newTestConfig()
+	v.Set("collectTemplateErrors", true)
+	v.Set("recordTemplateFuncUsage", true)
+
+	var buf bytes.Buffer
+	tr := newTranslator(bundle.New(), v, jww.NewNotepad(jww.LevelError, jww.LevelError, &buf, ioutil.Discard, "", 0))
+	require.NoError(t, tr.addTranslationFile("en.yaml", []byte(`- id: "place"
+  translation: "{{ ordinal .Count }} place"
+- id: "broken"
+  translation: "{{ index .Items 10 }}"
+`)))
+	tr.initFuncs()
+
+	require.Len(t, tr.BenchmarkAll(), 2)
+	require.Empty(t, tr.TemplateErrors())
+	require.Empty(t, tr.FuncUsage())
+	require.Empty(t, buf.String())
+
+	// Translating as usual is still recorded.
+	f := tr.Func("en")
+	require.Equal(t, "2nd place", f("place", 2))
+	f("broken", map[string]interface{}{"Items": "sample"})
+	require.Len(t, tr.TemplateErrors(), 1)
+	require.Equal(t, map[string][]string{"ordinal": {"place"}}, tr.FuncUsage())
+	require.Contains(t, buf.String(), "ERROR")
+}
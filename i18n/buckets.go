@@ -0,0 +1,242 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// bucketMessage is a translation with the text picked by which range of
+// counts the count falls in, e.g. for the entry:
+//
+//	id: items
+//	format: buckets
+//	translation:
+//	  "0": "No items"
+//	  "1": "One item"
+//	  "2-10": "A few items"
+//	  "11+": "{{ .Count }} items"
+//	  other: "Some items"
+//
+// The texts are templates, like other translations. The "other" text, if
+// any, is used for counts outside all the ranges.
+type bucketMessage struct {
+	// Sorted by min, and not overlapping.
+	buckets  []countBucket
+	other    string
+	hasOther bool
+}
+
+// countBucket is a range of counts, from min to max, both included, and the
+// escaped template for them.
+type countBucket struct {
+	min, max float64
+	src      string
+}
+
+type bucketsByMin []countBucket
+
+func (b bucketsByMin) Len() int           { return len(b) }
+func (b bucketsByMin) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b bucketsByMin) Less(i, j int) bool { return b[i].min < b[j].min }
+
+// parseBucketMessage parses the buckets of a translation, by range,
+// checking that their templates parse.
+func (t *Translator) parseBucketMessage(translation interface{}) (*bucketMessage, error) {
+	ranges, err := cast.ToStringMapStringE(translation)
+	if err != nil || len(ranges) == 0 {
+		return nil, fmt.Errorf("Expected a map from count ranges to texts")
+	}
+
+	msg := &bucketMessage{}
+	for r, src := range ranges {
+		if err := t.checkTemplate(src); err != nil {
+			return nil, err
+		}
+		if r == "other" {
+			msg.other, msg.hasOther = escapeTemplate(src), true
+			continue
+		}
+		b, err := parseCountRange(r)
+		if err != nil {
+			return nil, err
+		}
+		b.src = escapeTemplate(src)
+		msg.buckets = append(msg.buckets, b)
+	}
+
+	sort.Sort(bucketsByMin(msg.buckets))
+	for i := 1; i < len(msg.buckets); i++ {
+		if msg.buckets[i].min <= msg.buckets[i-1].max {
+			return nil, fmt.Errorf("Count ranges %s and %s overlap", msg.buckets[i-1], msg.buckets[i])
+		}
+	}
+
+	return msg, nil
+}
+
+// parseCountRange parses a range of counts, either a single count, e.g. "1",
+// two counts, e.g. "2-10", or a count and up, e.g. "11+".
+func parseCountRange(r string) (countBucket, error) {
+	s := strings.TrimSpace(r)
+	var b countBucket
+	var err error
+
+	switch {
+	case strings.HasSuffix(s, "+"):
+		b.min, err = strconv.ParseFloat(strings.TrimSpace(s[:len(s)-1]), 64)
+		b.max = math.Inf(1)
+	case strings.Index(s, "-") > 0:
+		i := strings.Index(s, "-")
+		b.min, err = strconv.ParseFloat(strings.TrimSpace(s[:i]), 64)
+		if err == nil {
+			b.max, err = strconv.ParseFloat(strings.TrimSpace(s[i+1:]), 64)
+		}
+	default:
+		b.min, err = strconv.ParseFloat(s, 64)
+		b.max = b.min
+	}
+
+	if err != nil || b.max < b.min {
+		return b, fmt.Errorf("Invalid count range %q", r)
+	}
+	return b, nil
+}
+
+func (b countBucket) String() string {
+	min, max := formatCount(b.min), formatCount(b.max)
+	switch {
+	case math.IsInf(b.max, 1):
+		return min + "+"
+	case b.min == b.max:
+		return min
+	}
+	return min + "-" + max
+}
+
+// formatCount formats a count of a range without an exponent, which
+// parseCountRange could not tell apart from a range.
+func formatCount(count float64) string {
+	return strconv.FormatFloat(count, 'f', -1, 64)
+}
+
+// sources returns the raw templates of m by count range, and "other".
+func (m *bucketMessage) sources() map[string]string {
+	sources := make(map[string]string, len(m.buckets)+1)
+	for _, b := range m.buckets {
+		sources[b.String()] = unescapeTemplate(b.src)
+	}
+	if m.hasOther {
+		sources["other"] = unescapeTemplate(m.other)
+	}
+	return sources
+}
+
+// templates returns the raw templates of m, in the order of the count
+// ranges, followed by the "other" one.
+func (m *bucketMessage) templates() []string {
+	templates := make([]string, 0, len(m.buckets)+1)
+	for _, b := range m.buckets {
+		templates = append(templates, unescapeTemplate(b.src))
+	}
+	if m.hasOther {
+		templates = append(templates, unescapeTemplate(m.other))
+	}
+	return templates
+}
+
+// isBucketSources reports whether value, a raw translation, is a map with a
+// count range as a key, which makes it a bucket message rather than a
+// plural one.
+func isBucketSources(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k := range m {
+		if isCountRange(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCountRange reports whether s is a count range of a bucket message.
+func isCountRange(s string) bool {
+	if s == "other" {
+		return false
+	}
+	_, err := parseCountRange(s)
+	return err == nil
+}
+
+// src returns the escaped template for count, if any.
+func (m *bucketMessage) src(count float64) (string, bool) {
+	for _, b := range m.buckets {
+		if count >= b.min && count <= b.max {
+			return b.src, true
+		}
+	}
+	return m.other, m.hasOther
+}
+
+// lookupBuckets translates translationID, a bucket message, into lang,
+// picking the text by the count in args. Without a count, it is the "other"
+// text.
+func (t *Translator) lookupBuckets(msg *bucketMessage, lang, translationID string, opts resolveOptions, args []interface{}) (string, bool) {
+	src, found := msg.other, msg.hasOther
+	if v, ok := countArg(args); ok {
+		if count, err := cast.ToFloat64E(v); err == nil {
+			src, found = msg.src(count)
+		}
+	}
+	if !found {
+		return "", false
+	}
+	return t.executeFound(lang, translationID, src, false, opts, args), true
+}
+
+// countArg returns the count in the translate func args, either passed
+// before the data or the Count in it.
+func countArg(args []interface{}) (interface{}, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	if len(args) > 1 || isNumber(args[0]) {
+		return args[0], true
+	}
+	return fieldValue(args[0], "Count")
+}
+
+func (t *Translator) addBucketMessage(lang, id string, msg *bucketMessage) {
+	if t.bucketMessages == nil {
+		t.bucketMessages = make(map[string]map[string]*bucketMessage)
+	}
+	if t.bucketMessages[lang] == nil {
+		t.bucketMessages[lang] = make(map[string]*bucketMessage)
+	}
+	t.bucketMessages[lang][id] = msg
+}
+
+// removeBucketMessage removes the bucket message for lang and id, if any,
+// so a later source can replace it with another translation.
+func (t *Translator) removeBucketMessage(lang, id string) {
+	delete(t.bucketMessages[lang], id)
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketMessages(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.json", `[
+  {
+    "id": "items",
+    "format": "buckets",
+    "translation": {"0": "No items", "1": "One item", "2-10": "A few items", "11+": "{{ .Count }} items"}
+  },
+  {
+    "id": "seats",
+    "format": "buckets",
+    "translation": {"0": "Sold out", "other": "Seats left"}
+  }
+]`})
+	f := tr.Func("en")
+
+	for i, test := range []struct {
+		id       string
+		args     []interface{}
+		expected string
+	}{
+		{"items", []interface{}{0}, "No items"},
+		{"items", []interface{}{1}, "One item"},
+		{"items", []interface{}{2}, "A few items"},
+		{"items", []interface{}{10}, "A few items"},
+		{"items", []interface{}{11}, "11 items"},
+		{"items", []interface{}{1500}, "1,500 items"},
+		{"items", []interface{}{map[string]interface{}{"Count": 5}}, "A few items"},
+		{"items", []interface{}{-1}, ""},
+		{"items", nil, ""},
+		{"seats", []interface{}{0}, "Sold out"},
+		{"seats", []interface{}{3}, "Seats left"},
+		{"seats", nil, "Seats left"},
+	} {
+		require.Equal(t, test.expected, f(test.id, test.args...), fmt.Sprintf("[%d]", i))
+	}
+
+	src, found := tr.Raw("en", "items")
+	require.True(t, found)
+	require.Equal(t, "{{ .Count }} items", src)
+	src, found = tr.Raw("en", "seats")
+	require.True(t, found)
+	require.Equal(t, "Seats left", src)
+
+	for i, translation := range []string{
+		`{"0": "None", "0-5": "Some"}`,
+		`{"5-1": "Some"}`,
+		`{"many": "Many"}`,
+		`"Items"`,
+		`{"0": "None", "1+": "{{ .Count items"}`,
+		`{"0": "None", "other": "{{ end }}"}`,
+	} {
+		tr := newTranslator(bundle.New(), newTestConfig(), logger)
+		err := tr.addLanguage("en", "en.json", []byte(`[{"id": "items", "format": "buckets", "translation": `+translation+`}]`))
+		require.Error(t, err, fmt.Sprintf("[%d]", i))
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CalendarFormatter is implemented by Formatters that can format dates in
+// calendars other than the Gregorian one.
+type CalendarFormatter interface {
+	// FormatDateCalendar formats d like FormatDate, in the calendar with the
+	// CLDR identifier calendar, e.g. "japanese".
+	FormatDateCalendar(lang string, d time.Time, style string, calendar string) (string, error)
+}
+
+// calendarData holds the CLDR conventions of a language for a calendar.
+type calendarData struct {
+	eras []string
+
+	// The year written for the first year of an era, if not 1, e.g. "元" in
+	// Japanese. Not used in the short style.
+	firstYear string
+
+	// Go time layouts for the date styles, with the era and the year in the
+	// era inserted for "{era}" and "{year}".
+	dateLayouts map[string]string
+}
+
+// calendarEras returns the era of a date, as an index into the eras of
+// calendarData, and the year in that era, by calendar identifier.
+var calendarEras = map[string]func(year, month, day int) (int, int){
+	"buddhist": func(year, month, day int) (int, int) {
+		return 0, year + 543
+	},
+	"japanese": japaneseEra,
+}
+
+// japaneseEras are the first days of the Meiji, Taishō, Shōwa, Heisei and
+// Reiwa eras, as yyyymmdd.
+var japaneseEras = []int{18681023, 19120730, 19261225, 19890108, 20190501}
+
+// japaneseEra returns the era and the year in the era of the date. Dates
+// before the Meiji era are counted in it.
+func japaneseEra(year, month, day int) (int, int) {
+	date := year*10000 + month*100 + day
+	era := 0
+	for i, start := range japaneseEras {
+		if date >= start {
+			era = i
+		}
+	}
+	return era, year - japaneseEras[era]/10000 + 1
+}
+
+// FormatDateCalendar formats d in lang like FormatDate, in the calendar
+// with the CLDR identifier calendar, e.g. "japanese" for the Japanese era
+// calendar or "buddhist". The Gregorian calendar is used if calendar is
+// empty or "gregorian". It fails if the calendar is not supported for lang,
+// or by the Formatter set.
+func (t *Translator) FormatDateCalendar(lang string, d time.Time, style string, calendar string) (string, error) {
+	if calendar == "" || calendar == "gregorian" {
+		return t.FormatDate(lang, d, style), nil
+	}
+
+	f, ok := t.getFormatter().(CalendarFormatter)
+	if !ok {
+		return "", fmt.Errorf("Calendar %q is not supported by the formatter", calendar)
+	}
+	return f.FormatDateCalendar(lang, d, style, calendar)
+}
+
+func (f defaultFormatter) FormatDateCalendar(lang string, d time.Time, style string, calendar string) (string, error) {
+	l := f.locales.find(lang)
+	c, found := l.calendars[calendar]
+	eraOf, known := calendarEras[calendar]
+	if !found || !known {
+		return "", fmt.Errorf("Calendar %q is not supported for language %q", calendar, lang)
+	}
+	if style == "time" {
+		return f.FormatDate(lang, d, style), nil
+	}
+
+	layout, found := c.dateLayouts[style]
+	if !found {
+		layout = c.dateLayouts["medium"]
+	}
+
+	year, month, day := d.Date()
+	era, eraYear := eraOf(year, int(month), day)
+	yearText := strconv.Itoa(eraYear)
+	if eraYear == 1 && c.firstYear != "" && style != "short" {
+		yearText = c.firstYear
+	}
+
+	// Go layouts have no tokens in braces, so the placeholders survive
+	// formatting.
+	formatted := formatDateLayout(l, d, layout)
+	formatted = strings.Replace(formatted, "{era}", c.eras[era], 1)
+	return strings.Replace(formatted, "{year}", yearText, 1), nil
+}
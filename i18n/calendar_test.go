@@ -0,0 +1,63 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDateCalendar(t *testing.T) {
+	tr := newTestTranslator()
+	d := time.Date(2017, time.March, 5, 15, 4, 0, 0, time.UTC)
+
+	for i, test := range []struct {
+		lang     string
+		d        time.Time
+		style    string
+		calendar string
+		expected string
+	}{
+		{"ja", d, "long", "japanese", "平成29年3月5日"},
+		{"ja", d, "short", "japanese", "平成29/03/05"},
+		{"ja", d, "time", "japanese", "15:04"},
+		{"ja", time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC), "long", "japanese", "令和元年5月1日"},
+		{"ja", time.Date(2019, time.April, 30, 0, 0, 0, 0, time.UTC), "long", "japanese", "平成31年4月30日"},
+		{"ja", time.Date(1989, time.January, 7, 0, 0, 0, 0, time.UTC), "medium", "japanese", "昭和64年1月7日"},
+		{"ja-JP", d, "long", "japanese", "平成29年3月5日"},
+		{"ja", d, "long", "", "2017年3月5日"},
+		{"ja", d, "long", "gregorian", "2017年3月5日"},
+		{"en", d, "long", "japanese", "March 5, 29 Heisei"},
+		{"en", d, "medium", "buddhist", "Mar 5, 2560 BE"},
+	} {
+		formatted, err := tr.FormatDateCalendar(test.lang, test.d, test.style, test.calendar)
+		require.NoError(t, err, fmt.Sprintf("[%d]", i))
+		require.Equal(t, test.expected, formatted, fmt.Sprintf("[%d]", i))
+	}
+
+	_, err := tr.FormatDateCalendar("ja", d, "long", "buddhist")
+	require.Error(t, err)
+	_, err = tr.FormatDateCalendar("en", d, "long", "hebrew")
+	require.Error(t, err)
+
+	tr.SetFormatter(stubFormatter{})
+	_, err = tr.FormatDateCalendar("ja", d, "long", "japanese")
+	require.Error(t, err)
+	formatted, err := tr.FormatDateCalendar("ja", d, "long", "")
+	require.NoError(t, err)
+	require.Equal(t, "date:ja:2017:long", formatted)
+}
@@ -0,0 +1,194 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"errors"
+	gotemplate "text/template"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/nicksnyder/go-i18n/i18n/language"
+	"github.com/nicksnyder/go-i18n/i18n/translation"
+)
+
+// CombineTranslators returns a Translator with the translations of all of
+// ts, e.g. those of the modules a site is composed of, so its Coverage
+// covers them all. The translators are in order of priority: if more than
+// one translates an id into a language, the first one wins, its plural
+// forms included. The same goes for the deprecated ids, the display names
+// and the template funcs. The variants with flags of all of them apply, in
+// that order. The combined Translator uses the configuration, logger,
+// environment and Formatter of the first. It is an error to pass none.
+func CombineTranslators(ts ...*Translator) (*Translator, error) {
+	if len(ts) == 0 {
+		return nil, errors.New("No translators to combine")
+	}
+
+	first := ts[0]
+	c := newTranslator(bundle.New(), first.cfg, first.logger)
+	c.icuMessages = make(map[string]map[string]*icuMessage)
+	c.bucketMessages = make(map[string]map[string]*bucketMessage)
+	c.environmentBundles = make(map[string]*bundle.Bundle)
+	c.deprecations = make(map[string]*deprecation)
+	c.displayNames = make(map[string]string)
+	c.funcs = make(map[string]gotemplate.FuncMap)
+
+	// The ids translated so far, by language.
+	claimed := make(map[string]map[string]bool)
+
+	// The ids of the variants and environment overrides added so far, by
+	// bundle key and language, and the function adding those of b, the
+	// bundle of t with the given key, with the format they have in t.
+	claimedOverrides := make(map[string]map[string]map[string]bool)
+	addOverrideFormats := func(t *Translator, bundleKey string, b *bundle.Bundle) {
+		if claimedOverrides[bundleKey] == nil {
+			claimedOverrides[bundleKey] = make(map[string]map[string]bool)
+		}
+		for lang, translations := range b.Translations() {
+			if claimedOverrides[bundleKey][lang] == nil {
+				claimedOverrides[bundleKey][lang] = make(map[string]bool)
+			}
+			for id := range translations {
+				if !claimedOverrides[bundleKey][lang][id] {
+					claimedOverrides[bundleKey][lang][id] = true
+					c.setHTML(bundleKey, lang, id, t.htmlTranslations[bundleKey][lang][id])
+				}
+			}
+		}
+	}
+
+	for i, t := range ts {
+		t.mu.RLock()
+		if i == 0 {
+			c.environment = t.environment
+			c.formatter = t.formatter
+		}
+		translations := t.bundle.Translations()
+		for lang, ids := range definedIDs(translations, t.icuMessages, t.bucketMessages) {
+			tag := language.Parse(lang)
+			if len(tag) == 0 {
+				continue
+			}
+			if claimed[lang] == nil {
+				claimed[lang] = make(map[string]bool)
+			}
+			for id := range ids {
+				if claimed[lang][id] {
+					continue
+				}
+				claimed[lang][id] = true
+				if tr, found := translations[lang][id]; found {
+					c.bundle.AddTranslation(tag[0], copyTranslation(tr))
+				}
+				if msg, found := t.icuMessages[lang][id]; found {
+					if c.icuMessages[lang] == nil {
+						c.icuMessages[lang] = make(map[string]*icuMessage)
+					}
+					c.icuMessages[lang][id] = msg
+				}
+				if msg, found := t.bucketMessages[lang][id]; found {
+					if c.bucketMessages[lang] == nil {
+						c.bucketMessages[lang] = make(map[string]*bucketMessage)
+					}
+					c.bucketMessages[lang][id] = msg
+				}
+				c.setHTML(baseBundleKey, lang, id, t.htmlTranslations[baseBundleKey][lang][id])
+			}
+		}
+
+		c.flagged = append(c.flagged, copyFlaggedBundles(t.flagged)...)
+		for _, fb := range t.flagged {
+			addOverrideFormats(t, variantBundleKey(fb.flags), fb.bundle)
+		}
+		for env, b := range t.environmentBundles {
+			addOverrideFormats(t, environmentBundleKey(env), b)
+			if c.environmentBundles[env] == nil {
+				c.environmentBundles[env] = bundle.New()
+			}
+			addMissingTranslations(c.environmentBundles[env], b)
+		}
+		for id, d := range t.deprecations {
+			if _, found := c.deprecations[id]; !found {
+				c.deprecations[id] = d
+			}
+		}
+		for lang, name := range t.displayNames {
+			if _, found := c.displayNames[lang]; !found {
+				c.displayNames[lang] = name
+			}
+		}
+		t.mu.RUnlock()
+
+		t.funcsMu.RLock()
+		for lang, funcs := range t.funcs {
+			if c.funcs[lang] == nil {
+				c.funcs[lang] = make(gotemplate.FuncMap)
+			}
+			for name, fn := range funcs {
+				if _, found := c.funcs[lang][name]; !found {
+					c.funcs[lang][name] = fn
+				}
+			}
+		}
+		t.funcsMu.RUnlock()
+	}
+
+	c.initFuncs()
+	return c, nil
+}
+
+// definedIDs returns the ids defined in translations, messages or buckets,
+// by language.
+func definedIDs(translations map[string]map[string]translation.Translation, messages map[string]map[string]*icuMessage, buckets map[string]map[string]*bucketMessage) map[string]map[string]bool {
+	ids := make(map[string]map[string]bool)
+	add := func(lang, id string) {
+		if ids[lang] == nil {
+			ids[lang] = make(map[string]bool)
+		}
+		ids[lang][id] = true
+	}
+	for lang, m := range translations {
+		for id := range m {
+			add(lang, id)
+		}
+	}
+	for lang, m := range messages {
+		for id := range m {
+			add(lang, id)
+		}
+	}
+	for lang, m := range buckets {
+		for id := range m {
+			add(lang, id)
+		}
+	}
+	return ids
+}
+
+// addMissingTranslations adds copies of the translations of src to dst,
+// unless dst already has a translation with the same id and language.
+func addMissingTranslations(dst, src *bundle.Bundle) {
+	existing := dst.Translations()
+	for lang, translations := range src.Translations() {
+		tag := language.Parse(lang)
+		if len(tag) == 0 {
+			continue
+		}
+		for id, tr := range translations {
+			if _, found := existing[lang][id]; !found {
+				dst.AddTranslation(tag[0], copyTranslation(tr))
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineTranslators(t *testing.T) {
+	site := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello from the site"
+- id: "files"
+  translation:
+    other: "{{.Count}} files"
+`},
+		testFile{"de.yaml", `- id: "hello"
+  translation: "Hallo"
+`},
+	)
+	theme := newTranslator(bundle.New(), newTestConfig(), logger)
+	require.NoError(t, theme.AddTemplateFunc("", "shout", func(s string) string { return s + "!" }))
+	for _, file := range []testFile{
+		{"en.yaml", `- id: "hello"
+  translation: "Hello from the theme"
+- id: "files"
+  translation:
+    one: "One file"
+    other: "Files"
+- id: "bye"
+  translation: "{{ shout \"Bye\" }}"
+- id: "messages"
+  format: icu
+  translation: "{count, plural, other{# messages}}"
+`},
+		{"fr.yaml", `- id: "bye"
+  translation: "Au revoir"
+`},
+	} {
+		require.NoError(t, theme.addTranslationFile(file.name, []byte(file.content)))
+	}
+	theme.initFuncs()
+
+	combined, err := CombineTranslators(site, theme)
+	require.NoError(t, err)
+
+	require.Equal(t, []LanguageCoverage{
+		{Lang: "de", Translated: []string{"hello"}, Missing: []string{"bye", "files", "messages"}},
+		{Lang: "en", Translated: []string{"bye", "files", "hello", "messages"}},
+		{Lang: "fr", Translated: []string{"bye"}, Missing: []string{"files", "hello", "messages"}},
+	}, combined.Coverage())
+
+	en := combined.Func("en")
+	require.Equal(t, "Hello from the site", en("hello"))
+	require.Equal(t, "2 files", en("files", 2))
+	require.Equal(t, "Bye!", en("bye"))
+	require.Equal(t, "3 messages", en("messages", map[string]interface{}{"count": 3}))
+	require.Equal(t, "Au revoir", combined.Func("fr")("bye"))
+
+	// The sources are left as they were.
+	require.Equal(t, "Hello from the theme", theme.Func("en")("hello"))
+	require.Equal(t, "One file", theme.Func("en")("files", 1))
+	require.Len(t, site.Coverage(), 2)
+}
+
+func TestCombineTranslatorsNone(t *testing.T) {
+	_, err := CombineTranslators()
+	require.Error(t, err)
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"sort"
+
+	"github.com/spf13/cast"
+)
+
+// LanguageCoverage tells which of the translation ids defined in any
+// language are translated in a language. The ids are sorted.
+type LanguageCoverage struct {
+	Lang string
+
+	// The ids with a translation.
+	Translated []string
+
+	// The ids defined in the language, but with an empty translation.
+	Empty []string
+
+	// The ids not defined in the language.
+	Missing []string
+}
+
+type coverageByLang []LanguageCoverage
+
+func (c coverageByLang) Len() int           { return len(c) }
+func (c coverageByLang) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c coverageByLang) Less(i, j int) bool { return c[i].Lang < c[j].Lang }
+
+// Coverage returns the coverage of every loaded language, sorted by
+// language. Variants with flags, and the ids listed in languageNeutralKeys,
+// e.g. a brand name, are not counted.
+func (t *Translator) Coverage() []LanguageCoverage {
+	translations := t.bundle.Translations()
+
+	t.mu.RLock()
+	messages := make(map[string]map[string]*icuMessage)
+	for lang, m := range t.icuMessages {
+		messages[lang] = m
+	}
+	buckets := make(map[string]map[string]*bucketMessage)
+	for lang, m := range t.bucketMessages {
+		buckets[lang] = m
+	}
+	t.mu.RUnlock()
+
+	langs := make(map[string]bool)
+	ids := make(map[string]bool)
+	for lang, m := range translations {
+		langs[lang] = true
+		for id := range m {
+			ids[id] = true
+		}
+	}
+	for lang, m := range messages {
+		langs[lang] = true
+		for id := range m {
+			ids[id] = true
+		}
+	}
+	for lang, m := range buckets {
+		langs[lang] = true
+		for id := range m {
+			ids[id] = true
+		}
+	}
+
+	neutral := t.languageNeutralKeys()
+	allIDs := make([]string, 0, len(ids))
+	for id := range ids {
+		if !neutral[id] {
+			allIDs = append(allIDs, id)
+		}
+	}
+	sort.Strings(allIDs)
+
+	var coverage []LanguageCoverage
+	for lang := range langs {
+		c := LanguageCoverage{Lang: lang}
+		for _, id := range allIDs {
+			if msg, found := messages[lang][id]; found {
+				if msg.src == "" {
+					c.Empty = append(c.Empty, id)
+				} else {
+					c.Translated = append(c.Translated, id)
+				}
+				continue
+			}
+			if _, found := buckets[lang][id]; found {
+				c.Translated = append(c.Translated, id)
+				continue
+			}
+			tr, found := translations[lang][id]
+			switch {
+			case !found:
+				c.Missing = append(c.Missing, id)
+			case emptyTranslation(tr):
+				c.Empty = append(c.Empty, id)
+			default:
+				c.Translated = append(c.Translated, id)
+			}
+		}
+		coverage = append(coverage, c)
+	}
+
+	sort.Sort(coverageByLang(coverage))
+
+	return coverage
+}
+
+// languageNeutralKeys returns the ids set in languageNeutralKeys, which are
+// meant to be the same in all languages, e.g. a version number.
+func (t *Translator) languageNeutralKeys() map[string]bool {
+	neutral := make(map[string]bool)
+	for _, id := range cast.ToStringSlice(t.cfg.Get("languageNeutralKeys")) {
+		neutral[id] = true
+	}
+	return neutral
+}
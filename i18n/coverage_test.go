@@ -0,0 +1,55 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverage(t *testing.T) {
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello"
+- id: "bye"
+  translation: "Bye"
+- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "messages"
+  format: icu
+  translation: "{count, plural, other{# messages}}"
+`},
+		testFile{"de.yaml", `- id: "hello"
+  translation: "Hallo"
+- id: "bye"
+  translation: ""
+- id: "files"
+- id: "messages"
+  format: icu
+  translation: ""
+`},
+		testFile{"fr.yaml", `- id: "hello"
+  translation: "Bonjour"
+`},
+	)
+
+	require.Equal(t, []LanguageCoverage{
+		{Lang: "de", Translated: []string{"hello"}, Empty: []string{"bye", "files", "messages"}},
+		{Lang: "en", Translated: []string{"bye", "files", "hello", "messages"}},
+		{Lang: "fr", Translated: []string{"hello"}, Missing: []string{"bye", "files", "messages"}},
+	}, translator.Coverage())
+}
@@ -0,0 +1,293 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/i18n/language"
+)
+
+// pluralSuffixSep separates the id from the plural category in the ids of
+// the CSV rows of plural forms, e.g. "files:one".
+const pluralSuffixSep = ":"
+
+// rowIDEscape escapes pluralSuffixSep, and itself, in the translation ids
+// of CSV rows, so "step:2" is written "step\:2", not read as a count range.
+const rowIDEscape = `\`
+
+// ExportCSV writes the translations of t to w as CSV, for editing in a
+// spreadsheet. The first row is "id" followed by the languages, the default
+// content language first. Every other row has an id and its translation
+// into each language, empty if missing. Plural forms get a row each, with
+// the category appended to the id, e.g. "files:one", and so do the texts of
+// bucket messages, with the count range, e.g. "items:2-10". A ":" in an id
+// is escaped with a backslash, as is a backslash. Variants with flags and
+// environment overrides are not exported.
+func (t *Translator) ExportCSV(w io.Writer) error {
+	translations := t.bundle.Translations()
+
+	t.mu.RLock()
+	messages := make(map[string]map[string]*icuMessage)
+	for lang, m := range t.icuMessages {
+		messages[lang] = m
+	}
+	buckets := make(map[string]map[string]*bucketMessage)
+	for lang, m := range t.bucketMessages {
+		buckets[lang] = m
+	}
+	t.mu.RUnlock()
+
+	defaultContentLanguage := t.cfg.GetString("defaultContentLanguage")
+	langSet := make(map[string]bool)
+	for lang := range translations {
+		langSet[lang] = true
+	}
+	for lang := range messages {
+		langSet[lang] = true
+	}
+	for lang := range buckets {
+		langSet[lang] = true
+	}
+	var others []string
+	for lang := range langSet {
+		if lang != defaultContentLanguage {
+			others = append(others, lang)
+		}
+	}
+	sort.Strings(others)
+	var langs []string
+	if langSet[defaultContentLanguage] {
+		langs = append(langs, defaultContentLanguage)
+	}
+	langs = append(langs, others...)
+
+	// The cells of every row, by row id and language.
+	cells := make(map[string]map[string]string)
+	set := func(rowID, lang, src string) {
+		if cells[rowID] == nil {
+			cells[rowID] = make(map[string]string)
+		}
+		cells[rowID][lang] = src
+	}
+	for lang, m := range translations {
+		for id, tr := range m {
+			if forms := pluralForms(tr); forms != nil {
+				for category, src := range forms {
+					set(joinRowID(id, string(category)), lang, src)
+				}
+			} else if src, found := templateSource(tr, language.Other); found {
+				set(joinRowID(id, ""), lang, src)
+			}
+		}
+	}
+	for lang, m := range messages {
+		for id, msg := range m {
+			set(joinRowID(id, ""), lang, msg.src)
+		}
+	}
+	for lang, m := range buckets {
+		for id, msg := range m {
+			for r, src := range msg.sources() {
+				set(joinRowID(id, r), lang, src)
+			}
+		}
+	}
+
+	rowIDs := make([]string, 0, len(cells))
+	for rowID := range cells {
+		rowIDs = append(rowIDs, rowID)
+	}
+	sort.Sort(byRowID(rowIDs))
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"id"}, langs...)); err != nil {
+		return err
+	}
+	for _, rowID := range rowIDs {
+		record := []string{rowID}
+		for _, lang := range langs {
+			record = append(record, cells[rowID][lang])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportCSV adds the translations in r, in the layout written by ExportCSV,
+// to t, merging them with those already loaded, plural forms included.
+// Ids with a count range row are imported as bucket messages. Empty cells
+// are skipped. Ids that are in the ICU MessageFormat syntax, or HTML, in
+// any language of t are imported as such.
+func (t *Translator) ImportCSV(r io.Reader) error {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return fmt.Errorf("Failed to read translations CSV: %s", err)
+	}
+	if len(records) == 0 || len(records[0]) == 0 || records[0][0] != "id" {
+		return fmt.Errorf("Failed to read translations CSV: the first row must be \"id\" followed by the languages")
+	}
+	langs := records[0][1:]
+
+	t.mu.RLock()
+	icuIDs := make(map[string]bool)
+	for _, m := range t.icuMessages {
+		for id := range m {
+			icuIDs[id] = true
+		}
+	}
+	htmlIDs := make(map[string]bool)
+	for _, m := range t.htmlTranslations[baseBundleKey] {
+		for id, isHTML := range m {
+			if isHTML {
+				htmlIDs[id] = true
+			}
+		}
+	}
+	t.mu.RUnlock()
+
+	// The entries to add, by language, in the order of the rows.
+	entries := make(map[string][]map[string]interface{})
+	plurals := make(map[string]map[string]map[string]interface{})
+	for _, record := range records[1:] {
+		id, category, err := splitRowID(record[0])
+		if err != nil {
+			return fmt.Errorf("Failed to read translations CSV: %s", err)
+		}
+		for i, lang := range langs {
+			if i+1 >= len(record) || record[i+1] == "" {
+				continue
+			}
+			src := record[i+1]
+
+			if category == "" {
+				entry := map[string]interface{}{"id": id, "translation": src}
+				switch {
+				case icuIDs[id]:
+					entry["format"] = "icu"
+				case htmlIDs[id]:
+					entry["format"] = "html"
+				}
+				entries[lang] = append(entries[lang], entry)
+				continue
+			}
+
+			if plurals[lang] == nil {
+				plurals[lang] = make(map[string]map[string]interface{})
+			}
+			entry, found := plurals[lang][id]
+			if !found {
+				entry = map[string]interface{}{"id": id, "translation": make(map[string]interface{})}
+				if htmlIDs[id] {
+					entry["format"] = "html"
+				}
+				plurals[lang][id] = entry
+				entries[lang] = append(entries[lang], entry)
+			}
+			entry["translation"].(map[string]interface{})[category] = src
+			if isCountRange(category) {
+				entry["format"] = "buckets"
+			}
+		}
+	}
+
+	for _, lang := range langs {
+		if len(entries[lang]) == 0 {
+			continue
+		}
+		content, err := json.Marshal(entries[lang])
+		if err != nil {
+			return err
+		}
+		if err := t.addLanguage(lang, lang+".json", content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinRowID returns the id of the CSV row of translationID, with the plural
+// category or count range suffix, if any.
+func joinRowID(translationID, suffix string) string {
+	escaped := strings.Replace(translationID, rowIDEscape, rowIDEscape+rowIDEscape, -1)
+	escaped = strings.Replace(escaped, pluralSuffixSep, rowIDEscape+pluralSuffixSep, -1)
+	if suffix == "" {
+		return escaped
+	}
+	return escaped + pluralSuffixSep + suffix
+}
+
+// splitRowID splits the id of a CSV row, as written by joinRowID, into the
+// translation id and the plural category or count range, if any.
+func splitRowID(rowID string) (string, string, error) {
+	var id []byte
+	for i := 0; i < len(rowID); i++ {
+		switch {
+		case strings.HasPrefix(rowID[i:], rowIDEscape) && i+len(rowIDEscape) < len(rowID):
+			i += len(rowIDEscape)
+			id = append(id, rowID[i])
+		case strings.HasPrefix(rowID[i:], pluralSuffixSep):
+			suffix := rowID[i+len(pluralSuffixSep):]
+			if pluralCategoryIndex(suffix) < 0 && !isCountRange(suffix) {
+				return "", "", fmt.Errorf("row %q has an invalid plural category or count range, escape %q in ids as %q", rowID, pluralSuffixSep, rowIDEscape+pluralSuffixSep)
+			}
+			return string(id), suffix, nil
+		default:
+			id = append(id, rowID[i])
+		}
+	}
+	return string(id), "", nil
+}
+
+// byRowID sorts row ids by translation id, then count ranges by their
+// lowest count, then plural forms in the order of pluralCategories.
+type byRowID []string
+
+func (r byRowID) Len() int      { return len(r) }
+func (r byRowID) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r byRowID) Less(i, j int) bool {
+	idI, suffixI, _ := splitRowID(r[i])
+	idJ, suffixJ, _ := splitRowID(r[j])
+	if idI != idJ {
+		return idI < idJ
+	}
+	rangeI, errI := parseCountRange(suffixI)
+	rangeJ, errJ := parseCountRange(suffixJ)
+	switch {
+	case errI == nil && errJ == nil:
+		return rangeI.min < rangeJ.min
+	case errI == nil || errJ == nil:
+		return errI == nil
+	}
+	return pluralCategoryIndex(suffixI) < pluralCategoryIndex(suffixJ)
+}
+
+// pluralCategoryIndex returns the index of category in pluralCategories,
+// or -1 if it is not a plural category.
+func pluralCategoryIndex(category string) int {
+	for i, c := range pluralCategories {
+		if string(c) == category {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportCSV(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello, {{ .Name }}!"
+- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "messages"
+  format: icu
+  translation: "{count, plural, other{# messages}}"
+- id: "bye"
+  translation: "Bye, \"friend\""
+`},
+		testFile{"es.yaml", `- id: "hello"
+  translation: "¡Hola, {{ .Name }}!"
+- id: "files"
+  translation:
+    one: "Un archivo"
+    other: "{{.Count}} archivos"
+`},
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, tr.ExportCSV(&buf))
+
+	expected := `id,en,es
+bye,"Bye, ""friend""",
+files:one,One file,Un archivo
+files:other,{{.Count}} files,{{.Count}} archivos
+hello,"Hello, {{ .Name }}!","¡Hola, {{ .Name }}!"
+messages,"{count, plural, other{# messages}}",
+`
+	require.Equal(t, expected, buf.String())
+
+	imported := newTranslator(bundle.New(), newTestConfig(), logger)
+	require.NoError(t, imported.AddLanguage("en", []byte(`- id: "messages"
+  format: icu
+  translation: "{count, plural, other{# old messages}}"
+`)))
+	require.NoError(t, imported.ImportCSV(&buf))
+
+	var roundTrip bytes.Buffer
+	require.NoError(t, imported.ExportCSV(&roundTrip))
+	require.Equal(t, expected, roundTrip.String())
+
+	es := imported.Func("es")
+	require.Equal(t, "Un archivo", es("files", 1))
+	require.Equal(t, "3 archivos", es("files", 3))
+	require.Equal(t, "¡Hola, Steve!", es("hello", map[string]interface{}{"Name": "Steve"}))
+	require.Equal(t, "2 messages", imported.Func("en")("messages", map[string]interface{}{"count": 2}))
+
+	require.Error(t, imported.ImportCSV(strings.NewReader("key,en\nhello,Hello\n")))
+}
+
+func TestExportImportCSVBuckets(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.json", `[
+  {
+    "id": "items",
+    "format": "buckets",
+    "translation": {"0": "No items", "2-10": "A few items", "11+": "{{ .Count }} items", "other": "Some items"}
+  },
+  {
+    "id": "files",
+    "translation": {"one": "One file", "other": "{{ .Count }} files"}
+  }
+]`})
+
+	var buf bytes.Buffer
+	require.NoError(t, tr.ExportCSV(&buf))
+
+	expected := `id,en
+files:one,One file
+files:other,{{ .Count }} files
+items:0,No items
+items:2-10,A few items
+items:11+,{{ .Count }} items
+items:other,Some items
+`
+	require.Equal(t, expected, buf.String())
+
+	imported := newTranslator(bundle.New(), newTestConfig(), logger)
+	require.NoError(t, imported.ImportCSV(&buf))
+
+	var roundTrip bytes.Buffer
+	require.NoError(t, imported.ExportCSV(&roundTrip))
+	require.Equal(t, expected, roundTrip.String())
+
+	en := imported.Func("en")
+	require.Equal(t, "A few items", en("items", 3))
+	require.Equal(t, "12 items", en("items", 12))
+	require.Equal(t, "Some items", en("items", 1))
+	require.Equal(t, "One file", en("files", 1))
+}
+
+func TestExportImportCSVAmbiguousIDs(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.json", `[
+  {"id": "nav:other", "translation": "Other pages"},
+  {"id": "step:2", "translation": "Second step"},
+  {"id": "path\\to", "translation": "Path"},
+  {"id": "a:b", "translation": {"one": "One b", "other": "{{ .Count }} b"}},
+  {"id": "terms", "format": "html", "translation": "Read the <strong>terms</strong>, {{ .Name }}"}
+]`})
+
+	var buf bytes.Buffer
+	require.NoError(t, tr.ExportCSV(&buf))
+
+	expected := `id,en
+a\:b:one,One b
+a\:b:other,{{ .Count }} b
+nav\:other,Other pages
+path\\to,Path
+step\:2,Second step
+terms,"Read the <strong>terms</strong>, {{ .Name }}"
+`
+	require.Equal(t, expected, buf.String())
+
+	// The HTML ids of the importing translator are kept HTML.
+	imported := newTranslator(bundle.New(), newTestConfig(), logger)
+	require.NoError(t, imported.AddLanguage("en", []byte(`- id: "terms"
+  format: html
+  translation: "Old <strong>terms</strong>"
+`)))
+	require.NoError(t, imported.ImportCSV(&buf))
+
+	var roundTrip bytes.Buffer
+	require.NoError(t, imported.ExportCSV(&roundTrip))
+	require.Equal(t, expected, roundTrip.String())
+
+	en := imported.Func("en")
+	require.Equal(t, "Other pages", en("nav:other"))
+	require.Equal(t, "Second step", en("step:2"))
+	require.Equal(t, "Path", en(`path\to`))
+	require.Equal(t, "3 b", en("a:b", 3))
+	require.Equal(t, "Read the <strong>terms</strong>, &lt;b&gt;", en("terms", map[string]interface{}{"Name": "<b>"}))
+
+	// An unescaped separator must be followed by a plural category or count range.
+	require.Error(t, imported.ImportCSV(strings.NewReader("id,en\nnav:home,Home\n")))
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/srcclr/hugo/helpers"
+)
+
+// deprecation redirects a translation id to the one replacing it.
+type deprecation struct {
+	replacement string
+
+	// A date, e.g. "2017-06-30", or a Hugo version, e.g. "0.22", after which
+	// the id is no longer redirected. Never, if empty.
+	removeAfter string
+}
+
+func (t *Translator) addDeprecation(id string, d *deprecation) {
+	if t.deprecations == nil {
+		t.deprecations = make(map[string]*deprecation)
+	}
+	t.deprecations[id] = d
+}
+
+// redirect returns the id that replaces the deprecated translationID, which
+// is translationID itself if it is not deprecated. Using a deprecated id
+// logs a warning, and is an error once it is past its removeAfter.
+func (t *Translator) redirect(translationID string) (string, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	id := translationID
+	for i := 0; i <= len(t.deprecations); i++ {
+		d, found := t.deprecations[id]
+		if !found {
+			return id, nil
+		}
+
+		expired, err := d.expired(t.now())
+		if err != nil {
+			return "", fmt.Errorf("Invalid removeAfter for deprecated translation %q: %s", id, err)
+		}
+		if expired {
+			return "", fmt.Errorf("Translation %q was removed after %s, use %q", id, d.removeAfter, d.replacement)
+		}
+
+		if d.removeAfter != "" {
+			helpers.DistinctWarnLog.Printf("Translation %q is deprecated and will be removed after %s, use %q.", id, d.removeAfter, d.replacement)
+		} else {
+			helpers.DistinctWarnLog.Printf("Translation %q is deprecated, use %q.", id, d.replacement)
+		}
+		id = d.replacement
+	}
+
+	return "", fmt.Errorf("Deprecated translation %q is redirected in a loop", translationID)
+}
+
+// expired reports whether d is past its removeAfter at now.
+func (d *deprecation) expired(now time.Time) (bool, error) {
+	switch {
+	case d.removeAfter == "":
+		return false, nil
+	case strings.Count(d.removeAfter, "-") == 2:
+		date, err := time.Parse("2006-01-02", d.removeAfter)
+		if err != nil {
+			return false, err
+		}
+		return !now.Before(date.AddDate(0, 0, 1)), nil
+	default:
+		return helpers.CompareVersion(d.removeAfter) < 0, nil
+	}
+}
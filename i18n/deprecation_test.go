@@ -0,0 +1,61 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecationRedirect(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.yaml", `- id: "greeting"
+  translation: "Hello!"
+- id: "hello"
+  deprecated: "greeting"
+  removeAfter: "2017-06-30"
+- id: "hi"
+  deprecated: "hello"
+- id: "hey"
+  deprecated: "greeting"
+  removeAfter: "0.19"
+- id: "yo"
+  deprecated: "greeting"
+  removeAfter: "99.0"
+- id: "ping"
+  deprecated: "pong"
+- id: "pong"
+  deprecated: "ping"
+`})
+	tr.now = func() time.Time { return time.Date(2017, time.June, 30, 23, 0, 0, 0, time.UTC) }
+
+	for _, id := range []string{"hello", "hi", "yo"} {
+		translated, err := tr.Translate("en", id, TranslateOptions{})
+		require.NoError(t, err, id)
+		require.Equal(t, "Hello!", translated, id)
+	}
+
+	_, err := tr.Translate("en", "hey", TranslateOptions{})
+	require.EqualError(t, err, `Translation "hey" was removed after 0.19, use "greeting"`)
+
+	_, err = tr.Translate("en", "ping", TranslateOptions{})
+	require.Error(t, err)
+
+	tr.now = func() time.Time { return time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC) }
+
+	_, err = tr.Translate("en", "hello", TranslateOptions{})
+	require.EqualError(t, err, `Translation "hello" was removed after 2017-06-30, use "greeting"`)
+	require.Equal(t, "", tr.Func("en")("hi"))
+}
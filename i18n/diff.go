@@ -0,0 +1,78 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+)
+
+// BundleDiff lists the translation ids that differ between two versions of
+// the translations for a language.
+type BundleDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty reports whether there are no differences.
+func (d BundleDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffLanguage compares the translations in newContent, in YAML or JSON,
+// with the translations loaded for lang, without adding them, e.g. to
+// preview the changes to a translation file. Ids loaded for lang, but not
+// in newContent, are reported as removed.
+func (t *Translator) DiffLanguage(lang string, newContent []byte) (BundleDiff, error) {
+	var diff BundleDiff
+
+	updated := newTranslator(bundle.New(), t.cfg, t.logger)
+	if err := updated.addTranslationFile(lang+".yaml", newContent); err != nil {
+		return diff, fmt.Errorf("Failed to parse translations for language %q: %s", lang, err)
+	}
+	newValues, err := updated.exportValues(lang)
+	if err != nil {
+		return diff, err
+	}
+
+	current, err := t.exportValues(lang)
+	if err != nil {
+		current = make(map[string]interface{})
+	}
+
+	for id, value := range newValues {
+		currentValue, found := current[id]
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, id)
+		case !reflect.DeepEqual(value, currentValue):
+			diff.Changed = append(diff.Changed, id)
+		}
+	}
+	for id := range current {
+		if _, found := newValues[id]; !found {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+
+	return diff, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLanguage(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.yaml", `- id: "hello"
+  translation: "Hello!"
+- id: "bye"
+  translation: "Bye!"
+- id: "readingTime"
+  translation:
+    one: "One minute read"
+    other: "{{ .Count }} minutes read"
+`})
+
+	diff, err := tr.DiffLanguage("en", []byte(`- id: "hello"
+  translation: "Hello!"
+- id: "welcome"
+  translation: "Welcome!"
+- id: "readingTime"
+  translation:
+    one: "One minute"
+    other: "{{ .Count }} minutes read"
+`))
+	require.NoError(t, err)
+	require.Equal(t, BundleDiff{
+		Added:   []string{"welcome"},
+		Removed: []string{"bye"},
+		Changed: []string{"readingTime"},
+	}, diff)
+
+	// Nothing is applied.
+	require.Equal(t, "Bye!", tr.Func("en")("bye"))
+	require.Equal(t, "", tr.Func("en")("welcome"))
+
+	diff, err = tr.DiffLanguage("fr", []byte(`- id: "hello"
+  translation: "Bonjour !"
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello"}, diff.Added)
+	require.False(t, diff.Empty())
+
+	require.NoError(t, tr.addTranslationFile("en.json", []byte(`[
+  {"id": "items", "format": "buckets", "translation": {"0": "No items", "1+": "{{ .Count }} items"}}
+]`)))
+	diff, err = tr.DiffLanguage("en", []byte(`- id: "hello"
+  translation: "Hello!"
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"bye", "items", "readingTime"}, diff.Removed)
+
+	_, err = tr.DiffLanguage("en", []byte("- id: \"broken\"\n  translation: \"{{ .Oops \""))
+	require.Error(t, err)
+}
@@ -0,0 +1,37 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import "github.com/nicksnyder/go-i18n/i18n/bundle"
+
+// SetEnvironment sets the environment the site is built for, e.g. "staging",
+// replacing the one in translationEnvironment. Translation entries with that
+// environment then override the others with the same id.
+func (t *Translator) SetEnvironment(env string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.environment = env
+}
+
+// environmentBundle returns the bundle for the overrides of env, creating
+// it if needed.
+func (t *Translator) environmentBundle(env string) *bundle.Bundle {
+	if t.environmentBundles == nil {
+		t.environmentBundles = make(map[string]*bundle.Bundle)
+	}
+	if t.environmentBundles[env] == nil {
+		t.environmentBundles[env] = bundle.New()
+	}
+	return t.environmentBundles[env]
+}
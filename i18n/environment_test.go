@@ -0,0 +1,49 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentOverrides(t *testing.T) {
+	v := newTestConfig()
+	v.Set("translationEnvironment", "staging")
+
+	translator := newTranslatorFromFiles(t, v,
+		testFile{"en.yaml", `- id: "welcome"
+  translation: "Welcome"
+- id: "welcome"
+  environment: staging
+  translation: "[STAGING] Welcome"
+- id: "bye"
+  translation: "Bye"
+`},
+		testFile{"de.yaml", `- id: "welcome"
+  translation: "Willkommen"
+`})
+
+	require.Equal(t, "[STAGING] Welcome", translator.Func("en")("welcome"))
+	require.Equal(t, "Bye", translator.Func("en")("bye"))
+	// Overrides apply per language.
+	require.Equal(t, "Willkommen", translator.Func("de")("welcome"))
+
+	translator.SetEnvironment("production")
+	require.Equal(t, "Welcome", translator.Func("en")("welcome"))
+
+	translator.SetEnvironment("")
+	require.Equal(t, "Welcome", translator.Func("en")("welcome"))
+}
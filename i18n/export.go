@@ -0,0 +1,163 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/nicksnyder/go-i18n/i18n/language"
+)
+
+// ExportFlatJSON writes the translations for lang to w as one JSON object
+// from id to the raw, unexecuted translation, e.g. for use by a client side
+// i18n library. Plural translations are exported as an object from plural
+// category to translation, and bucket messages as an object from count
+// range to translation. Translation variants are not exported.
+func (t *Translator) ExportFlatJSON(lang string, w io.Writer) error {
+	values, err := t.exportValues(lang)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(values)
+}
+
+// exportValues returns the raw translations for lang by id.
+func (t *Translator) exportValues(lang string) (map[string]interface{}, error) {
+	translations, found := t.bundle.Translations()[lang]
+
+	t.mu.RLock()
+	messages, buckets := t.icuMessages[lang], t.bucketMessages[lang]
+	t.mu.RUnlock()
+
+	if !found && len(messages) == 0 && len(buckets) == 0 {
+		return nil, fmt.Errorf("No translations found for language %q", lang)
+	}
+
+	values := make(map[string]interface{})
+	for id, tr := range translations {
+		if forms := pluralForms(tr); forms != nil {
+			m := make(map[string]string)
+			for category, src := range forms {
+				m[string(category)] = src
+			}
+			values[id] = m
+			continue
+		}
+		if src, found := templateSource(tr, language.Other); found {
+			values[id] = src
+		}
+	}
+
+	for id, msg := range messages {
+		values[id] = msg.src
+	}
+	for id, msg := range buckets {
+		values[id] = msg.sources()
+	}
+
+	return values, nil
+}
+
+// RoundTripStable reports whether the translations for lang are loaded back
+// unchanged from what ExportFlatJSON writes. ICU messages are not, as the
+// export does not tell them apart from other translations, and neither are
+// bucket messages with only an "other" text, which look like plural ones.
+func (t *Translator) RoundTripStable(lang string) bool {
+	var buf bytes.Buffer
+	if err := t.ExportFlatJSON(lang, &buf); err != nil {
+		return false
+	}
+
+	imported, err := t.importFlatJSON(lang, buf.Bytes())
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(t.fingerprint(lang), imported.fingerprint(lang))
+}
+
+// importFlatJSON loads the translations for lang in the format written by
+// ExportFlatJSON into a new Translator.
+func (t *Translator) importFlatJSON(lang string, content []byte) (*Translator, error) {
+	var values map[string]interface{}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+
+	entries := make([]map[string]interface{}, 0, len(values))
+	for id, value := range values {
+		entry := map[string]interface{}{"id": id, "translation": value}
+		if isBucketSources(value) {
+			entry["format"] = "buckets"
+		}
+		entries = append(entries, entry)
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := newTranslator(bundle.New(), t.cfg, t.logger)
+	if err := imported.addTranslationFile(lang+".json", b); err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+// fingerprint returns a hash of the raw translations for lang, and of the
+// format of each.
+func (t *Translator) fingerprint(lang string) []byte {
+	var lines []string
+
+	for id, tr := range t.bundle.Translations()[lang] {
+		if forms := pluralForms(tr); forms != nil {
+			for category, src := range forms {
+				lines = append(lines, fmt.Sprintf("%s\x00plural\x00%s\x00%s", id, category, src))
+			}
+			continue
+		}
+		if src, found := templateSource(tr, language.Other); found {
+			lines = append(lines, fmt.Sprintf("%s\x00template\x00%s", id, src))
+		}
+	}
+
+	t.mu.RLock()
+	for id, msg := range t.icuMessages[lang] {
+		lines = append(lines, fmt.Sprintf("%s\x00icu\x00%s", id, msg.src))
+	}
+	for id, msg := range t.bucketMessages[lang] {
+		for r, src := range msg.sources() {
+			lines = append(lines, fmt.Sprintf("%s\x00buckets\x00%s\x00%s", id, r, src))
+		}
+	}
+	t.mu.RUnlock()
+
+	sort.Strings(lines)
+
+	h := sha256.New()
+	for _, line := range lines {
+		io.WriteString(h, line+"\n")
+	}
+	return h.Sum(nil)
+}
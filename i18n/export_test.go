@@ -0,0 +1,95 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportFlatJSON(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello, {{ .Name }} & welcome!"
+- id: "readingTime"
+  translation:
+    one: "One minute read"
+    other: "{{ .Count }} minutes read"
+`},
+		icuTestFiles[0])
+
+	var buf bytes.Buffer
+	require.NoError(t, tr.ExportFlatJSON("en", &buf))
+
+	var exported map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+	require.Equal(t, map[string]interface{}{
+		"hello": "Hello, {{ .Name }} & welcome!",
+		"readingTime": map[string]interface{}{
+			"one":   "One minute read",
+			"other": "{{ .Count }} minutes read",
+		},
+		"messages": "{gender, select, male{He has {count, plural, one{# message} other{# messages}}} female{She has {count, plural, one{# message} other{# messages}}} other{They have {count, plural, one{# message} other{# messages}}}}",
+		"guests":   "{count, plural, =0{Nobody came} one{{gender, select, female{She} male{He} other{They}} came alone} other{# guests came, it''s a '{party}'}}",
+	}, exported)
+	require.Contains(t, buf.String(), "&")
+
+	require.Error(t, tr.ExportFlatJSON("fr", &buf))
+}
+
+func TestRoundTripStable(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello, {{ .Name }} & <b>welcome</b>!"
+- id: "empty"
+  translation: ""
+- id: "readingTime"
+  translation:
+    one: "One minute read"
+    other: "{{ .Count }} minutes read"
+`},
+		testFile{"pl.yaml", `- id: "readingTime"
+  translation:
+    one: "{{ .Count }} minuta"
+    few: "{{ .Count }} minuty"
+    many: "{{ .Count }} minut"
+    other: "{{ .Count }} minuty"
+`})
+
+	require.True(t, tr.RoundTripStable("en"))
+	require.True(t, tr.RoundTripStable("pl"))
+	require.False(t, tr.RoundTripStable("fr"))
+
+	// ICU messages come back as regular translations.
+	tr = newTranslatorFromFiles(t, newTestConfig(), icuTestFiles...)
+	require.False(t, tr.RoundTripStable("pl"))
+
+	tr = newTranslatorFromFiles(t, newTestConfig(), testFile{"en.json", `[
+  {
+    "id": "items",
+    "format": "buckets",
+    "translation": {"0": "No items", "2-10": "A few items", "11+": "{{ .Count }} items", "other": "Some items"}
+  }
+]`})
+	require.True(t, tr.RoundTripStable("en"))
+
+	var buf bytes.Buffer
+	require.NoError(t, tr.ExportFlatJSON("en", &buf))
+	var values map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &values))
+	require.Equal(t, map[string]interface{}{"0": "No items", "2-10": "A few items", "11+": "{{ .Count }} items", "other": "Some items"}, values["items"])
+}
@@ -0,0 +1,691 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jww "github.com/spf13/jwalterweatherman"
+	"golang.org/x/text/language"
+)
+
+// Formatter formats numbers, percentages, currency amounts and dates using
+// the conventions of a language.
+type Formatter interface {
+	// FormatNumber formats n with the given number of decimals.
+	FormatNumber(lang string, n float64, decimals int) string
+
+	// FormatPercent formats n, where 1 is 100%, with the given number of decimals.
+	FormatPercent(lang string, n float64, decimals int) string
+
+	// FormatCurrency formats n as an amount in the ISO 4217 currency, e.g. "EUR".
+	FormatCurrency(lang string, n float64, currency string) string
+
+	// FormatCompact formats n in the short compact form, e.g. "1.2K".
+	FormatCompact(lang string, n int64) string
+
+	// FormatDate formats d in one of the "short", "medium" or "long" date
+	// styles, or the "time" style, in the location of d.
+	FormatDate(lang string, d time.Time, style string) string
+}
+
+// SetFormatter replaces the Formatter used by t.
+func (t *Translator) SetFormatter(f Formatter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.formatter = f
+}
+
+// FormatNumber formats n with the given number of decimals in lang.
+func (t *Translator) FormatNumber(lang string, n float64, decimals int) string {
+	return t.getFormatter().FormatNumber(lang, n, decimals)
+}
+
+// FormatPercent formats n as a percentage in lang, e.g. 0.25 as "25%".
+func (t *Translator) FormatPercent(lang string, n float64, decimals int) string {
+	return t.getFormatter().FormatPercent(lang, n, decimals)
+}
+
+// FormatCurrency formats n as an amount of currency in lang.
+func (t *Translator) FormatCurrency(lang string, n float64, currency string) string {
+	return t.getFormatter().FormatCurrency(lang, n, currency)
+}
+
+// CompactNumber formats n in lang in the short compact form used for
+// approximate counts, e.g. 1200 as "1.2K" in English. The built-in suffixes
+// are those of the languages with compact data in localeLoaders; the others
+// get the whole number.
+func (t *Translator) CompactNumber(lang string, n int64) string {
+	return t.getFormatter().FormatCompact(lang, n)
+}
+
+// FormatDate formats d in lang using the "short", "medium", "long" or "time"
+// style.
+func (t *Translator) FormatDate(lang string, d time.Time, style string) string {
+	return t.getFormatter().FormatDate(lang, d, style)
+}
+
+// ParseNumber parses s, a number formatted using the conventions of lang,
+// e.g. "1.234,56" in German. It uses the built-in locale data, also if
+// another Formatter is set.
+func (t *Translator) ParseNumber(lang string, s string) (float64, error) {
+	n, err := parseNumber(t.locales.find(lang), s)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse %q as a number in language %q", s, lang)
+	}
+	return n, nil
+}
+
+// NumberExample returns an example of a number formatted in lang, e.g.
+// "1,234.56" in English and "1.234,56" in German, to show the expected
+// input format in forms.
+func (t *Translator) NumberExample(lang string) string {
+	return t.FormatNumber(lang, 1234.56, 2)
+}
+
+// FormatDateIn formats d in lang like FormatDate, but in the time zone loc,
+// e.g. "3:00 PM" for an instant at 15:00 in loc.
+func (t *Translator) FormatDateIn(lang string, d time.Time, style string, loc *time.Location) string {
+	return t.getFormatter().FormatDate(lang, d.In(loc), style)
+}
+
+// FormatDateInZone is like FormatDateIn, with the time zone given by its
+// IANA name, e.g. "Europe/Oslo".
+func (t *Translator) FormatDateInZone(lang string, d time.Time, style string, zone string) (string, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return "", fmt.Errorf("Failed to load time zone %q: %s", zone, err)
+	}
+	return t.FormatDateIn(lang, d, style, loc), nil
+}
+
+// FormatNumberContext is like FormatNumber, but gives up on the Formatter
+// when ctx is done, e.g. when its deadline passes, returning n unformatted.
+func (t *Translator) FormatNumberContext(ctx context.Context, lang string, n float64, decimals int) string {
+	return t.formatContext(ctx, "number", lang, func(f Formatter) string {
+		return f.FormatNumber(lang, n, decimals)
+	}, strconv.FormatFloat(n, 'f', decimals, 64))
+}
+
+// FormatPercentContext is like FormatPercent, but gives up on the Formatter
+// when ctx is done, returning the percentage unformatted.
+func (t *Translator) FormatPercentContext(ctx context.Context, lang string, n float64, decimals int) string {
+	return t.formatContext(ctx, "percentage", lang, func(f Formatter) string {
+		return f.FormatPercent(lang, n, decimals)
+	}, strconv.FormatFloat(n*100, 'f', decimals, 64)+"%")
+}
+
+// FormatCurrencyContext is like FormatCurrency, but gives up on the
+// Formatter when ctx is done, returning the amount followed by the currency code.
+func (t *Translator) FormatCurrencyContext(ctx context.Context, lang string, n float64, currency string) string {
+	return t.formatContext(ctx, "currency amount", lang, func(f Formatter) string {
+		return f.FormatCurrency(lang, n, currency)
+	}, strconv.FormatFloat(n, 'f', 2, 64)+" "+strings.ToUpper(currency))
+}
+
+// CompactNumberContext is like CompactNumber, but gives up on the Formatter
+// when ctx is done, returning n unformatted.
+func (t *Translator) CompactNumberContext(ctx context.Context, lang string, n int64) string {
+	return t.formatContext(ctx, "number", lang, func(f Formatter) string {
+		return f.FormatCompact(lang, n)
+	}, strconv.FormatInt(n, 10))
+}
+
+// FormatDateContext is like FormatDate, but gives up on the Formatter when
+// ctx is done, returning d in the ISO 8601 format.
+func (t *Translator) FormatDateContext(ctx context.Context, lang string, d time.Time, style string) string {
+	fallback := d.Format("2006-01-02")
+	if style == "time" {
+		fallback = d.Format("15:04")
+	}
+	return t.formatContext(ctx, "date", lang, func(f Formatter) string {
+		return f.FormatDate(lang, d, style)
+	}, fallback)
+}
+
+// formatContext returns the result of format, or fallback if ctx is done
+// first. The Formatter cannot be interrupted, so a slow one keeps running
+// in the background until it returns.
+func (t *Translator) formatContext(ctx context.Context, what, lang string, format func(Formatter) string, fallback string) string {
+	f := t.getFormatter()
+	done := make(chan string, 1)
+	go func() {
+		done <- format(f)
+	}()
+
+	select {
+	case formatted := <-done:
+		return formatted
+	case <-ctx.Done():
+		t.logger.WARN.Printf("Formatting %s in language %q gave up: %s", what, lang, ctx.Err())
+		return fallback
+	}
+}
+
+func (t *Translator) getFormatter() Formatter {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.formatter
+}
+
+// localeData holds the CLDR conventions of a language needed for formatting.
+type localeData struct {
+	decimal string
+	group   string
+
+	// The minimum number of digits before the first group separator, if
+	// more than one, e.g. 2 in Spanish, which groups 12.345 but not 1234.
+	minGroupingDigits int
+
+	// The separator between a number and "%", if any.
+	percentSpace string
+
+	// Whether the currency symbol goes after the amount, and the separator
+	// between them.
+	currencyAfter bool
+	currencySpace string
+
+	// Go time layouts for the date styles. If set, months and shortMonths
+	// replace the English month names in the formatted date.
+	dateLayouts map[string]string
+	months      []string
+	shortMonths []string
+
+	// The eras and date layouts of the non-Gregorian calendars supported
+	// for the language, by calendar identifier.
+	calendars map[string]*calendarData
+
+	// The ellipsis for truncated text, if not "…".
+	ellipsis string
+
+	// The primary and secondary opening and closing quotation marks, if
+	// not “, ”, ‘ and ’.
+	quotes []string
+
+	// The CLDR short compact number suffixes for thousands, millions,
+	// billions and trillions. Numbers are not abbreviated for empty ones.
+	compact []string
+}
+
+var (
+	englishCompact = []string{"K", "M", "B", "T"}
+
+	germanMonths      = []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+	frenchMonths      = []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+	frenchShortMonths = []string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."}
+
+	// The Polish and Russian months are in the genitive, as in a date.
+	polishMonths       = []string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"}
+	russianMonths      = []string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"}
+	russianShortMonths = []string{"янв.", "февр.", "мар.", "апр.", "мая", "июн.", "июл.", "авг.", "сент.", "окт.", "нояб.", "дек."}
+
+	// localeLoaders create the locale data of the languages known to the
+	// default formatter. A loader is only called for a language in use.
+	// The data is a hand-picked subset of CLDR: the vendored golang.org/x/text
+	// has no number or date formatting, and only these languages are known.
+	localeLoaders = map[string]func() *localeData{
+		"en": func() *localeData {
+			return &localeData{
+				decimal: ".", group: ",",
+				dateLayouts: map[string]string{"short": "1/2/06", "medium": "Jan 2, 2006", "long": "January 2, 2006", "time": "3:04 PM"},
+				calendars: map[string]*calendarData{
+					"buddhist": {
+						eras:        []string{"BE"},
+						dateLayouts: map[string]string{"short": "1/2/{year} {era}", "medium": "Jan 2, {year} {era}", "long": "January 2, {year} {era}"},
+					},
+					"japanese": {
+						eras:        []string{"Meiji", "Taishō", "Shōwa", "Heisei", "Reiwa"},
+						dateLayouts: map[string]string{"short": "1/2/{year} {era}", "medium": "Jan 2, {year} {era}", "long": "January 2, {year} {era}"},
+					},
+				},
+				compact: englishCompact,
+			}
+		},
+		"en-gb": func() *localeData {
+			return &localeData{
+				decimal: ".", group: ",",
+				dateLayouts: map[string]string{"short": "02/01/2006", "medium": "2 Jan 2006", "long": "2 January 2006", "time": "15:04"},
+				compact:     englishCompact,
+			}
+		},
+		"de": func() *localeData {
+			return &localeData{
+				decimal: ",", group: ".", percentSpace: "\u00a0",
+				currencyAfter: true, currencySpace: "\u00a0",
+				dateLayouts: map[string]string{"short": "02.01.06", "medium": "02.01.2006", "long": "2. January 2006", "time": "15:04"},
+				quotes:      []string{"„", "“", "‚", "‘"},
+				months:      germanMonths,
+				compact:     []string{"", "\u00a0Mio.", "\u00a0Mrd.", "\u00a0Bio."},
+			}
+		},
+		"fr": func() *localeData {
+			return &localeData{
+				decimal: ",", group: "\u00a0", percentSpace: "\u00a0",
+				currencyAfter: true, currencySpace: "\u00a0",
+				dateLayouts: map[string]string{"short": "02/01/2006", "medium": "2 Jan 2006", "long": "2 January 2006", "time": "15:04"},
+				months:      frenchMonths,
+				shortMonths: frenchShortMonths,
+				quotes:      []string{"«\u00a0", "\u00a0»", "“", "”"},
+				compact:     []string{"\u00a0k", "\u00a0M", "\u00a0Md", "\u00a0Bn"},
+			}
+		},
+		"es": func() *localeData {
+			return &localeData{
+				decimal: ",", group: ".", minGroupingDigits: 2, percentSpace: "\u00a0",
+				currencyAfter: true, currencySpace: "\u00a0",
+				dateLayouts: map[string]string{"short": "2/1/06", "medium": "2 Jan 2006", "long": "2 de January de 2006", "time": "15:04"},
+				months:      []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+				shortMonths: []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+				quotes:      []string{"«", "»", "“", "”"},
+				compact:     []string{"\u00a0mil", "\u00a0M", "\u00a0mil\u00a0M", "\u00a0B"},
+			}
+		},
+		"it": func() *localeData {
+			return &localeData{
+				decimal: ",", group: ".",
+				currencyAfter: true, currencySpace: "\u00a0",
+				dateLayouts: map[string]string{"short": "02/01/06", "medium": "2 Jan 2006", "long": "2 January 2006", "time": "15:04"},
+				months:      []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+				shortMonths: []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+				quotes:      []string{"«", "»", "“", "”"},
+				compact:     []string{"", "\u00a0Mln", "\u00a0Mrd", "\u00a0Bln"},
+			}
+		},
+		"nl": func() *localeData {
+			return &localeData{
+				decimal: ",", group: ".",
+				currencySpace: "\u00a0",
+				dateLayouts:   map[string]string{"short": "02-01-2006", "medium": "2 Jan 2006", "long": "2 January 2006", "time": "15:04"},
+				months:        []string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+				shortMonths:   []string{"jan.", "feb.", "mrt.", "apr.", "mei", "jun.", "jul.", "aug.", "sep.", "okt.", "nov.", "dec."},
+				compact:       []string{"K", "\u00a0mln.", "\u00a0mld.", "\u00a0bln."},
+			}
+		},
+		"pt": func() *localeData {
+			return &localeData{
+				decimal: ",", group: ".",
+				currencySpace: "\u00a0",
+				dateLayouts:   map[string]string{"short": "02/01/2006", "medium": "2 de Jan de 2006", "long": "2 de January de 2006", "time": "15:04"},
+				months:        []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+				shortMonths:   []string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+				compact:       []string{"\u00a0mil", "\u00a0mi", "\u00a0bi", "\u00a0tri"},
+			}
+		},
+		"pl": func() *localeData {
+			return &localeData{
+				decimal: ",", group: "\u00a0", minGroupingDigits: 2,
+				currencyAfter: true, currencySpace: "\u00a0",
+				dateLayouts: map[string]string{"short": "02.01.2006", "medium": "2 Jan 2006", "long": "2 January 2006", "time": "15:04"},
+				months:      polishMonths,
+				shortMonths: []string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+				quotes:      []string{"„", "”", "«", "»"},
+				compact:     []string{"\u00a0tys.", "\u00a0mln", "\u00a0mld", "\u00a0bln"},
+			}
+		},
+		"ru": func() *localeData {
+			return &localeData{
+				decimal: ",", group: "\u00a0", percentSpace: "\u00a0",
+				currencyAfter: true, currencySpace: "\u00a0",
+				dateLayouts: map[string]string{"short": "02.01.2006", "medium": "2 Jan 2006 г.", "long": "2 January 2006 г.", "time": "15:04"},
+				months:      russianMonths,
+				shortMonths: russianShortMonths,
+				quotes:      []string{"«", "»", "„", "“"},
+				compact:     []string{"\u00a0тыс.", "\u00a0млн", "\u00a0млрд", "\u00a0трлн"},
+			}
+		},
+		"nb": func() *localeData {
+			return &localeData{
+				decimal: ",", group: "\u00a0", percentSpace: "\u00a0",
+				currencyAfter: true, currencySpace: "\u00a0",
+				dateLayouts: map[string]string{"short": "02.01.2006", "medium": "02.01.2006", "long": "2. January 2006", "time": "15:04"},
+				quotes:      []string{"«", "»", "‘", "’"},
+				months:      []string{"januar", "februar", "mars", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "desember"},
+				compact:     []string{"k", "\u00a0mill.", "\u00a0mrd.", "\u00a0bill."},
+			}
+		},
+		"ja": func() *localeData {
+			return &localeData{
+				decimal: ".", group: ",",
+				dateLayouts: map[string]string{"short": "2006/01/02", "medium": "2006/01/02", "long": "2006年1月2日", "time": "15:04"},
+				quotes:      []string{"「", "」", "『", "』"},
+				calendars: map[string]*calendarData{
+					"japanese": {
+						eras:        []string{"明治", "大正", "昭和", "平成", "令和"},
+						firstYear:   "元",
+						dateLayouts: map[string]string{"short": "{era}{year}/01/02", "medium": "{era}{year}年1月2日", "long": "{era}{year}年1月2日"},
+					},
+				},
+			}
+		},
+		"zh": func() *localeData {
+			return &localeData{
+				decimal: ".", group: ",",
+				dateLayouts: map[string]string{"short": "2006/1/2", "medium": "2006年1月2日", "long": "2006年1月2日", "time": "15:04"},
+				ellipsis:    "……",
+			}
+		},
+	}
+
+	currencySymbols = map[string]string{
+		"EUR": "€", "GBP": "£", "JPY": "¥", "USD": "$",
+	}
+
+	// Currencies without minor units.
+	currencyNoDecimals = map[string]bool{"JPY": true, "KRW": true}
+)
+
+// defaultFormatter is the built-in Formatter. It knows the conventions of the
+// languages in localeLoaders and formats the rest like formattingFallbackLocale
+// or English, warning once per language.
+type defaultFormatter struct {
+	locales *localeCache
+}
+
+func (f defaultFormatter) FormatNumber(lang string, n float64, decimals int) string {
+	return formatNumber(f.locales.find(lang), n, decimals)
+}
+
+func (f defaultFormatter) FormatPercent(lang string, n float64, decimals int) string {
+	l := f.locales.find(lang)
+	return formatNumber(l, n*100, decimals) + l.percentSpace + "%"
+}
+
+func (f defaultFormatter) FormatCurrency(lang string, n float64, currency string) string {
+	l := f.locales.find(lang)
+	currency = strings.ToUpper(currency)
+
+	decimals := 2
+	if currencyNoDecimals[currency] {
+		decimals = 0
+	}
+
+	symbol, found := currencySymbols[currency]
+	if !found {
+		symbol = currency
+	}
+
+	amount := formatNumber(l, math.Abs(n), decimals)
+	sign := ""
+	if n < 0 {
+		sign = "-"
+	}
+
+	if l.currencyAfter {
+		return sign + amount + l.currencySpace + symbol
+	}
+	return sign + symbol + l.currencySpace + amount
+}
+
+func (f defaultFormatter) FormatCompact(lang string, n int64) string {
+	l := f.locales.find(lang)
+
+	abs := math.Abs(float64(n))
+	for i := len(l.compact) - 1; i >= 0; i-- {
+		unit := math.Pow(1000, float64(i+1))
+		if abs < unit || l.compact[i] == "" {
+			continue
+		}
+
+		// Keep two significant digits, e.g. 1.2K and 12K, but 123K.
+		v := float64(n) / unit
+		decimals := 0
+		if math.Abs(v) < 10 {
+			decimals = 1
+		}
+		scale := math.Pow(10, float64(decimals))
+		v = math.Floor(v*scale+0.5) / scale
+		if math.Abs(v) >= 1000 && i+1 < len(l.compact) && l.compact[i+1] != "" {
+			// Rounded up to the next unit.
+			v, i = v/1000, i+1
+			decimals = 1
+		}
+		if v == math.Trunc(v) {
+			decimals = 0
+		}
+		return formatNumber(l, v, decimals) + l.compact[i]
+	}
+
+	return formatNumber(l, float64(n), 0)
+}
+
+func (f defaultFormatter) FormatDate(lang string, d time.Time, style string) string {
+	l := f.locales.find(lang)
+	layout, found := l.dateLayouts[style]
+	if !found {
+		layout = l.dateLayouts["medium"]
+	}
+
+	return formatDateLayout(l, d, layout)
+}
+
+// formatDateLayout formats d with the Go time layout, using the month names
+// of l.
+func formatDateLayout(l *localeData, d time.Time, layout string) string {
+	formatted := d.Format(layout)
+	month := d.Month().String()
+	switch {
+	case l.months != nil && strings.Contains(layout, "January"):
+		formatted = strings.Replace(formatted, month, l.months[d.Month()-1], 1)
+	case l.shortMonths != nil && strings.Contains(layout, "Jan"):
+		formatted = strings.Replace(formatted, month[:3], l.shortMonths[d.Month()-1], 1)
+	}
+	return formatted
+}
+
+// localeCache holds the locale data of the languages used so far, so only
+// those are ever loaded.
+type localeCache struct {
+	mu     sync.Mutex
+	loaded map[string]*localeData
+
+	// The language to format like if there is no locale data for the one
+	// requested, English if there is none for it either.
+	fallback string
+
+	logger *jww.Notepad
+	// The languages without locale data warned about so far.
+	warned map[string]bool
+}
+
+func newLocaleCache(fallback string, logger *jww.Notepad) *localeCache {
+	return &localeCache{
+		loaded:   make(map[string]*localeData),
+		fallback: fallback,
+		logger:   logger,
+		warned:   make(map[string]bool),
+	}
+}
+
+// load returns the locale data for the given key, e.g. "en-gb", loading it
+// on first use.
+func (c *localeCache) load(key string) (*localeData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if l, found := c.loaded[key]; found {
+		return l, true
+	}
+	loader, found := localeLoaders[key]
+	if !found {
+		return nil, false
+	}
+	l := loader()
+	c.loaded[key] = l
+	return l, true
+}
+
+// find returns the locale data for lang, trying the language without its
+// region before falling back to the fallback language, then to English.
+// Using a fallback is logged, once per language.
+func (c *localeCache) find(lang string) *localeData {
+	if l, found := c.findExact(lang); found {
+		return l
+	}
+
+	used := "en"
+	if c.fallback != "" {
+		if _, found := c.findExact(c.fallback); found {
+			used = c.fallback
+		}
+	}
+	c.warnFallback(lang, used)
+
+	l, _ := c.findExact(used)
+	return l
+}
+
+func (c *localeCache) warnFallback(lang, used string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.warned[lang] || c.logger == nil {
+		return
+	}
+	c.warned[lang] = true
+	c.logger.WARN.Printf("No number and date formatting data for language %q, formatting it like %q", lang, used)
+}
+
+// lookup returns the locale data for lang, or for the fallback language, if
+// any, without falling back to English.
+func (c *localeCache) lookup(lang string) (*localeData, bool) {
+	if l, found := c.findExact(lang); found {
+		return l, true
+	}
+	if c.fallback == "" {
+		return nil, false
+	}
+	return c.findExact(c.fallback)
+}
+
+// findExact returns the locale data for lang, or for the language without
+// its region, if any.
+func (c *localeCache) findExact(lang string) (*localeData, bool) {
+	tag := language.Make(lang)
+	if l, found := c.load(strings.ToLower(tag.String())); found {
+		return l, true
+	}
+	base, _ := tag.Base()
+	return c.load(base.String())
+}
+
+// formatNumber formats n with decimals and the separators of l.
+func formatNumber(l *localeData, n float64, decimals int) string {
+	return formatDecimal(l, strconv.FormatFloat(n, 'f', decimals, 64))
+}
+
+// formatDecimal formats s, a decimal number as formatted by strconv, with
+// the separators of l.
+func formatDecimal(l *localeData, s string) string {
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	minGroupingDigits := 1
+	if l.minGroupingDigits > 1 {
+		minGroupingDigits = l.minGroupingDigits
+	}
+
+	var grouped []string
+	if len(intPart) >= 3+minGroupingDigits {
+		for len(intPart) > 3 {
+			grouped = append([]string{intPart[len(intPart)-3:]}, grouped...)
+			intPart = intPart[:len(intPart)-3]
+		}
+	}
+	grouped = append([]string{intPart}, grouped...)
+
+	formatted := strings.Join(grouped, l.group)
+	if fracPart != "" {
+		formatted += l.decimal + fracPart
+	}
+	if negative && strings.Trim(formatted, "0"+l.group+l.decimal) != "" {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// parseNumber parses s, formatted with the separators of l. Only digits,
+// the separators of l and a leading sign are allowed, and group separators
+// only between groups of three digits, so "1.5" is not 15 in German.
+func parseNumber(l *localeData, s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.Replace(s, "\u2212", "-", 1)
+
+	if strings.TrimSpace(l.group) == "" {
+		// Also accept other spaces than the one in the locale data.
+		for _, space := range []string{" ", "\u202f"} {
+			s = strings.Replace(s, space, l.group, -1)
+		}
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.Index(s, l.decimal); i >= 0 {
+		intPart, fracPart = s[:i], s[i+len(l.decimal):]
+		if fracPart == "" {
+			return 0, fmt.Errorf("missing decimals in %q", s)
+		}
+	}
+	if !isDigits(fracPart) {
+		return 0, fmt.Errorf("invalid decimals in %q", s)
+	}
+
+	groups := strings.Split(intPart, l.group)
+	for i, group := range groups {
+		valid := isDigits(group)
+		switch {
+		case len(groups) == 1:
+		case i == 0:
+			valid = valid && group != "" && len(group) <= 3
+		default:
+			valid = valid && len(group) == 3
+		}
+		if !valid {
+			return 0, fmt.Errorf("misplaced separator in %q", s)
+		}
+	}
+	intPart = strings.Join(groups, "")
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("no digits in %q", s)
+	}
+
+	if fracPart != "" {
+		return strconv.ParseFloat(sign+intPart+"."+fracPart, 64)
+	}
+	return strconv.ParseFloat(sign+intPart, 64)
+}
+
+// isDigits returns whether s consists of the ASCII digits only.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,327 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/stretchr/testify/require"
+)
+
+type stubFormatter struct{}
+
+func (stubFormatter) FormatNumber(lang string, n float64, decimals int) string {
+	return fmt.Sprintf("number:%s:%v:%d", lang, n, decimals)
+}
+
+func (stubFormatter) FormatPercent(lang string, n float64, decimals int) string {
+	return fmt.Sprintf("percent:%s:%v:%d", lang, n, decimals)
+}
+
+func (stubFormatter) FormatCurrency(lang string, n float64, currency string) string {
+	return fmt.Sprintf("currency:%s:%v:%s", lang, n, currency)
+}
+
+func (stubFormatter) FormatCompact(lang string, n int64) string {
+	return fmt.Sprintf("compact:%s:%d", lang, n)
+}
+
+func (stubFormatter) FormatDate(lang string, d time.Time, style string) string {
+	return fmt.Sprintf("date:%s:%d:%s", lang, d.Year(), style)
+}
+
+// slowFormatter is a stubFormatter that blocks until released, like one
+// fetching locale data over a slow network.
+type slowFormatter struct {
+	stubFormatter
+	release chan struct{}
+}
+
+func (f slowFormatter) FormatNumber(lang string, n float64, decimals int) string {
+	<-f.release
+	return f.stubFormatter.FormatNumber(lang, n, decimals)
+}
+
+func (f slowFormatter) FormatDate(lang string, d time.Time, style string) string {
+	<-f.release
+	return f.stubFormatter.FormatDate(lang, d, style)
+}
+
+func TestFormatterDefault(t *testing.T) {
+	tr := newTestTranslator()
+	d := time.Date(2017, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	for i, test := range []struct {
+		got      string
+		expected string
+	}{
+		{tr.FormatNumber("en", 1234567.891, 2), "1,234,567.89"},
+		{tr.FormatNumber("de", 1234567.891, 2), "1.234.567,89"},
+		{tr.FormatNumber("fr", -1234.5, 1), "-1\u00a0234,5"},
+		{tr.FormatNumber("en-US", 999, 0), "999"},
+		{tr.FormatNumber("es", 1234.5, 1), "1234,5"},
+		{tr.FormatNumber("es", 12345.5, 1), "12.345,5"},
+		{tr.FormatNumber("pl", 1234567, 0), "1\u00a0234\u00a0567"},
+		{tr.FormatNumber("pt-BR", 1234.5, 2), "1.234,50"},
+		{tr.FormatPercent("en", 0.256, 1), "25.6%"},
+		{tr.FormatPercent("de", 0.5, 0), "50\u00a0%"},
+		{tr.FormatCurrency("en", 1234.5, "usd"), "$1,234.50"},
+		{tr.FormatCurrency("de", -12.5, "EUR"), "-12,50\u00a0€"},
+		{tr.FormatCurrency("ja", 1500, "JPY"), "¥1,500"},
+		{tr.FormatCurrency("en", 3, "CHF"), "CHF3.00"},
+		{tr.FormatCurrency("nl", 12.5, "EUR"), "€\u00a012,50"},
+		{tr.FormatCurrency("it", 12.5, "EUR"), "12,50\u00a0€"},
+		{tr.FormatDate("en", d, "long"), "March 5, 2017"},
+		{tr.FormatDate("en-GB", d, "short"), "05/03/2017"},
+		{tr.FormatDate("de", d, "long"), "5. März 2017"},
+		{tr.FormatDate("fr", d, "medium"), "5 mars 2017"},
+		{tr.FormatDate("fr", d, "unknown"), "5 mars 2017"},
+		{tr.FormatDate("ja", d, "long"), "2017年3月5日"},
+		{tr.FormatDate("es", d, "long"), "5 de marzo de 2017"},
+		{tr.FormatDate("it", d, "medium"), "5 mar 2017"},
+		{tr.FormatDate("nl", d, "medium"), "5 mrt. 2017"},
+		{tr.FormatDate("pt", d, "medium"), "5 de mar. de 2017"},
+		{tr.FormatDate("pl", d, "long"), "5 marca 2017"},
+		{tr.FormatDate("ru", d, "long"), "5 марта 2017 г."},
+	} {
+		require.Equal(t, test.expected, test.got, fmt.Sprintf("[%d]", i))
+	}
+}
+
+func TestFormatterLoadsLocalesLazily(t *testing.T) {
+	tr := newTestTranslator()
+	require.Empty(t, tr.locales.loaded)
+
+	require.Equal(t, "1,000", tr.FormatNumber("en-US", 1000, 0))
+	require.Equal(t, "March 5, 2017", tr.FormatDate("en", time.Date(2017, time.March, 5, 0, 0, 0, 0, time.UTC), "long"))
+	n, err := tr.ParseNumber("en", "1,234.5")
+	require.NoError(t, err)
+	require.Equal(t, 1234.5, n)
+
+	loaded := tr.locales.loaded
+	require.Len(t, loaded, 1)
+	require.Contains(t, loaded, "en")
+	require.NotContains(t, loaded, "de")
+
+	// Languages without locale data use the English one.
+	require.Equal(t, "1,000", tr.FormatNumber("xx", 1000, 0))
+	require.Len(t, loaded, 1)
+
+	require.Equal(t, "1.000", tr.FormatNumber("de", 1000, 0))
+	require.Len(t, loaded, 2)
+	require.Contains(t, loaded, "de")
+}
+
+func TestFormatterWarnsWithoutLocaleData(t *testing.T) {
+	var buf bytes.Buffer
+	tr := NewTranslator(bundle.New(), newTestConfig(), jww.NewNotepad(jww.LevelWarn, jww.LevelError, &buf, ioutil.Discard, "", 0))
+
+	require.Equal(t, "1,000", tr.FormatNumber("xx", 1000, 0))
+	require.Equal(t, "1,000", tr.FormatNumber("xx", 1000, 0))
+	require.Equal(t, 1, strings.Count(buf.String(), `No number and date formatting data for language "xx", formatting it like "en"`))
+
+	buf.Reset()
+	require.Equal(t, "1,000", tr.FormatNumber("en-AU", 1000, 0))
+	require.Equal(t, "1.000", tr.FormatNumber("de", 1000, 0))
+	require.Empty(t, buf.String())
+}
+
+func TestFormattingFallbackLocale(t *testing.T) {
+	v := newTestConfig()
+	v.Set("formattingFallbackLocale", "de-AT")
+	tr := NewTranslator(bundle.New(), v, logger)
+
+	require.Equal(t, "1.234,5", tr.FormatNumber("xx", 1234.5, 1))
+	require.Equal(t, "1.234,5", tr.FormatNumber("xx-YY", 1234.5, 1))
+	require.Equal(t, "5. März 2017", tr.FormatDate("xx", time.Date(2017, time.March, 5, 0, 0, 0, 0, time.UTC), "long"))
+	n, err := tr.ParseNumber("xx", "1.234,5")
+	require.NoError(t, err)
+	require.Equal(t, 1234.5, n)
+
+	// Languages with locale data are not affected.
+	require.Equal(t, "1,234.5", tr.FormatNumber("en", 1234.5, 1))
+
+	v.Set("formattingFallbackLocale", "yy")
+	tr = NewTranslator(bundle.New(), v, logger)
+	require.Equal(t, "1,234.5", tr.FormatNumber("xx", 1234.5, 1))
+}
+
+func TestFormatterStub(t *testing.T) {
+	tr := newTestTranslator()
+	tr.SetFormatter(stubFormatter{})
+
+	require.Equal(t, "number:de:1.5:2", tr.FormatNumber("de", 1.5, 2))
+	require.Equal(t, "percent:fr:0.5:0", tr.FormatPercent("fr", 0.5, 0))
+	require.Equal(t, "currency:en:3:EUR", tr.FormatCurrency("en", 3, "EUR"))
+	require.Equal(t, "compact:de:1200", tr.CompactNumber("de", 1200))
+	require.Equal(t, "date:ja:2017:long", tr.FormatDate("ja", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), "long"))
+}
+
+func TestFormatterContextDeadline(t *testing.T) {
+	var buf bytes.Buffer
+	tr := newTranslator(newTestTranslator().bundle, newTestConfig(), jww.NewNotepad(jww.LevelWarn, jww.LevelError, &buf, ioutil.Discard, "", 0))
+
+	release := make(chan struct{})
+	defer close(release)
+	tr.SetFormatter(slowFormatter{release: release})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	d := time.Date(2017, time.March, 5, 15, 4, 0, 0, time.UTC)
+
+	require.Equal(t, "1234.50", tr.FormatNumberContext(ctx, "de", 1234.5, 2))
+	require.Equal(t, "2017-03-05", tr.FormatDateContext(ctx, "de", d, "long"))
+	require.Equal(t, "15:04", tr.FormatDateContext(ctx, "de", d, "time"))
+	require.Equal(t, 3, strings.Count(buf.String(), "WARN"))
+	require.Contains(t, buf.String(), `Formatting number in language "de" gave up: context deadline exceeded`)
+
+	// Formatters done in time are not affected.
+	require.Equal(t, "currency:en:3:EUR", tr.FormatCurrencyContext(context.Background(), "en", 3, "EUR"))
+	require.Equal(t, "compact:de:1200", tr.CompactNumberContext(context.Background(), "de", 1200))
+	require.Equal(t, "percent:fr:0.5:0", tr.FormatPercentContext(context.Background(), "fr", 0.5, 0))
+}
+
+func TestParseNumber(t *testing.T) {
+	tr := newTestTranslator()
+
+	for i, test := range []struct {
+		lang     string
+		s        string
+		expected float64
+	}{
+		{"de", "1.234,56", 1234.56},
+		{"de", "-1.234.567", -1234567},
+		{"de", "0,5", 0.5},
+		{"en", "1,234.56", 1234.56},
+		{"en", "  1234.5 ", 1234.5},
+		{"en", "\u22123", -3},
+		{"fr", "1\u00a0234,5", 1234.5},
+		{"fr", "1 234,5", 1234.5},
+		{"en", "+1,234", 1234},
+		{"en", ".5", 0.5},
+		{"es", "1234,5", 1234.5},
+	} {
+		n, err := tr.ParseNumber(test.lang, test.s)
+		require.NoError(t, err, fmt.Sprintf("[%d] %s", i, test.s))
+		require.Equal(t, test.expected, n, fmt.Sprintf("[%d] %s", i, test.s))
+	}
+
+	for i, test := range []struct {
+		lang string
+		s    string
+	}{
+		{"de", "1,234.56"},
+		{"en", "1.234,56"},
+		{"en", "abc"},
+		{"en", ""},
+		{"de", "1.5"},
+		{"de", "12.34,5"},
+		{"en", "1,2345"},
+		{"en", ",123"},
+		{"en", "NaN"},
+		{"en", "Inf"},
+		{"en", "-Inf"},
+		{"en", "1e5"},
+		{"en", "0x10"},
+		{"en", "1_000"},
+		{"en", "--1"},
+		{"en", "1-"},
+		{"en", "1."},
+		{"en", "-"},
+	} {
+		_, err := tr.ParseNumber(test.lang, test.s)
+		require.Error(t, err, fmt.Sprintf("[%d] %s", i, test.s))
+	}
+
+	// Parsing is the inverse of formatting.
+	for _, lang := range []string{"en", "de", "fr", "nb"} {
+		n, err := tr.ParseNumber(lang, tr.FormatNumber(lang, -9876543.21, 2))
+		require.NoError(t, err, lang)
+		require.Equal(t, -9876543.21, n, lang)
+	}
+}
+
+func TestNumberExample(t *testing.T) {
+	tr := newTestTranslator()
+
+	require.Equal(t, "1,234.56", tr.NumberExample("en"))
+	require.Equal(t, "1.234,56", tr.NumberExample("de"))
+	require.Equal(t, "1\u00a0234,56", tr.NumberExample("fr"))
+
+	// Examples parse back.
+	for _, lang := range []string{"en", "de", "fr"} {
+		n, err := tr.ParseNumber(lang, tr.NumberExample(lang))
+		require.NoError(t, err, lang)
+		require.Equal(t, 1234.56, n, lang)
+	}
+}
+
+func TestFormatDateIn(t *testing.T) {
+	tr := newTestTranslator()
+	d := time.Date(2017, time.March, 5, 23, 0, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	newYork := time.FixedZone("EST", -5*60*60)
+
+	require.Equal(t, "6:00 PM", tr.FormatDateIn("en", d, "time", newYork))
+	require.Equal(t, "March 5, 2017", tr.FormatDateIn("en", d, "long", newYork))
+	require.Equal(t, "8:00 AM", tr.FormatDateIn("en", d, "time", tokyo))
+	require.Equal(t, "March 6, 2017", tr.FormatDateIn("en", d, "long", tokyo))
+	require.Equal(t, "08:00", tr.FormatDateIn("de", d, "time", tokyo))
+
+	s, err := tr.FormatDateInZone("en", d, "time", "UTC")
+	require.NoError(t, err)
+	require.Equal(t, "11:00 PM", s)
+
+	_, err = tr.FormatDateInZone("en", d, "time", "Nowhere/Special")
+	require.Error(t, err)
+}
+
+func TestCompactNumber(t *testing.T) {
+	tr := newTestTranslator()
+
+	for i, test := range []struct {
+		lang     string
+		n        int64
+		expected string
+	}{
+		{"en", 999, "999"},
+		{"en", 1000, "1K"},
+		{"en", 1200, "1.2K"},
+		{"en", 12345, "12K"},
+		{"en", 123456, "123K"},
+		{"en", 999999, "1M"},
+		{"en", 1500000, "1.5M"},
+		{"en", -1500000, "-1.5M"},
+		{"en", 2000000000, "2B"},
+		{"de", 1200, "1.200"},
+		{"de", 1500000, "1,5\u00a0Mio."},
+		{"fr", 1200, "1,2\u00a0k"},
+		{"ja", 1200, "1,200"},
+		{"zh", 1500000, "1,500,000"},
+		{"es", 1200, "1,2\u00a0mil"},
+		{"it", 1200, "1.200"},
+		{"ru", 1500000, "1,5\u00a0млн"},
+	} {
+		require.Equal(t, test.expected, tr.CompactNumber(test.lang, test.n), fmt.Sprintf("[%d] %s %d", i, test.lang, test.n))
+	}
+}
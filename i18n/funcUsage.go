@@ -0,0 +1,63 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FuncUsage returns, by template func, the sorted ids of the translations
+// that called it since t was created. This is only recorded with
+// recordTemplateFuncUsage set, as it makes translating slower. The funcs
+// built into Go templates are not included.
+func (t *Translator) FuncUsage() map[string][]string {
+	t.funcUsageMu.Lock()
+	defer t.funcUsageMu.Unlock()
+
+	usage := make(map[string][]string)
+	for name, ids := range t.funcUsage {
+		for id := range ids {
+			usage[name] = append(usage[name], id)
+		}
+		sort.Strings(usage[name])
+	}
+	return usage
+}
+
+// recordingFunc returns a func like fn, the template func name, recording
+// that translationID calls it.
+func (t *Translator) recordingFunc(translationID, name string, fn interface{}) interface{} {
+	v := reflect.ValueOf(fn)
+	return reflect.MakeFunc(v.Type(), func(in []reflect.Value) []reflect.Value {
+		t.recordFuncUse(translationID, name)
+		if v.Type().IsVariadic() {
+			return v.CallSlice(in)
+		}
+		return v.Call(in)
+	}).Interface()
+}
+
+func (t *Translator) recordFuncUse(translationID, name string) {
+	t.funcUsageMu.Lock()
+	defer t.funcUsageMu.Unlock()
+
+	if t.funcUsage == nil {
+		t.funcUsage = make(map[string]map[string]bool)
+	}
+	if t.funcUsage[name] == nil {
+		t.funcUsage[name] = make(map[string]bool)
+	}
+	t.funcUsage[name][translationID] = true
+}
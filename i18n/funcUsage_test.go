@@ -0,0 +1,66 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncUsage(t *testing.T) {
+	files := `- id: "title"
+  translation: "{{ upper .Title }}"
+- id: "place"
+  translation: "{{ if .Show }}{{ upper .Name }}{{ end }} {{ ordinal .Place }}"
+- id: "list"
+  translation: "{{ join .Title .Name }}"
+- id: "plain"
+  translation: "{{ .Name }}"
+`
+	data := map[string]interface{}{"Title": "Hugo", "Name": "steve", "Place": 2, "Show": false}
+
+	for _, record := range []bool{false, true} {
+		v := newTestConfig()
+		v.Set("recordTemplateFuncUsage", record)
+
+		tr := newTranslator(bundle.New(), v, logger)
+		require.NoError(t, tr.AddTemplateFunc("", "upper", strings.ToUpper))
+		require.NoError(t, tr.AddTemplateFunc("", "join", func(s ...string) string { return strings.Join(s, ", ") }))
+		require.NoError(t, tr.AddTemplateFunc("", "unused", strings.ToLower))
+		require.NoError(t, tr.addTranslationFile("en.yaml", []byte(files)))
+		tr.initFuncs()
+
+		f := tr.Func("en")
+		require.Equal(t, "HUGO", f("title", data), fmt.Sprint(record))
+		require.Equal(t, " 2nd", f("place", data), fmt.Sprint(record))
+		require.Equal(t, "Hugo, steve", f("list", data), fmt.Sprint(record))
+		require.Equal(t, "steve", f("plain", data), fmt.Sprint(record))
+
+		if !record {
+			require.Empty(t, tr.FuncUsage())
+			continue
+		}
+
+		// Only what is called is recorded, not what is in the template.
+		require.Equal(t, map[string][]string{
+			"upper":   {"title"},
+			"ordinal": {"place"},
+			"join":    {"list"},
+		}, tr.FuncUsage())
+	}
+}
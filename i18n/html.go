@@ -0,0 +1,277 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"golang.org/x/net/html"
+)
+
+var (
+	// The tags allowed in HTML translations if htmlTranslationTags is not set.
+	defaultHTMLTranslationTags = []string{"a", "abbr", "b", "br", "code", "em", "i", "small", "span", "strong", "sub", "sup"}
+
+	// The attributes allowed in HTML translations if
+	// htmlTranslationAttributes is not set.
+	defaultHTMLTranslationAttributes = []string{"class", "dir", "href", "id", "lang", "rel", "target", "title"}
+
+	// The attributes holding a URL, which must be relative or have a safe
+	// scheme.
+	htmlURLAttributes = map[string]bool{
+		"action": true, "background": true, "cite": true, "formaction": true,
+		"href": true, "poster": true, "src": true, "xlink:href": true,
+	}
+
+	safeURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true, "tel": true}
+
+	// The elements whose content is not HTML, and is stripped along with
+	// them if they are not allowed.
+	htmlRawTextElements = map[string]bool{
+		"iframe": true, "noembed": true, "noframes": true, "noscript": true, "plaintext": true,
+		"script": true, "style": true, "textarea": true, "title": true, "xmp": true,
+	}
+)
+
+// checkHTML checks that src, the source of an HTML translation or what it
+// executed to, only has the allowed tags and attributes, and only safe URLs.
+// Attribute values are checked as the browser sees them, i.e. with entities
+// decoded. It returns src with the disallowed tags, the content of those
+// holding raw text, e.g. a script, the disallowed attributes, comments and
+// doctypes removed, and a description of each.
+func (t *Translator) checkHTML(src string) (string, []string) {
+	tags := t.allowedHTML("htmlTranslationTags", defaultHTMLTranslationTags)
+	attrs := t.allowedHTML("htmlTranslationAttributes", defaultHTMLTranslationAttributes)
+
+	var (
+		buf      bytes.Buffer
+		problems []string
+		// The disallowed raw text element being stripped, if any.
+		stripping string
+	)
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// The end of src.
+			break
+		}
+		raw := string(z.Raw())
+		tok := z.Token()
+
+		if stripping != "" {
+			if tt == html.EndTagToken && tok.Data == stripping {
+				stripping = ""
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			buf.WriteString(raw)
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			if !tags[tok.Data] {
+				problems = append(problems, fmt.Sprintf("<%s>", tok.Data))
+				if tt == html.StartTagToken && htmlRawTextElements[tok.Data] {
+					stripping = tok.Data
+				}
+				continue
+			}
+
+			var kept []html.Attribute
+			var unsafe []string
+			for _, attr := range tok.Attr {
+				switch {
+				case !attrs[attr.Key]:
+					unsafe = append(unsafe, fmt.Sprintf("attribute %q in <%s>", attr.Key, tok.Data))
+				case htmlURLAttributes[attr.Key] && !safeURL(attr.Val):
+					unsafe = append(unsafe, fmt.Sprintf("unsafe URL in <%s>", tok.Data))
+				default:
+					kept = append(kept, attr)
+				}
+			}
+			if len(unsafe) == 0 {
+				buf.WriteString(raw)
+				continue
+			}
+			problems = append(problems, unsafe...)
+			tok.Attr = kept
+			buf.WriteString(tok.String())
+		case html.CommentToken:
+			problems = append(problems, "comment")
+		case html.DoctypeToken:
+			problems = append(problems, "doctype")
+		}
+	}
+
+	return buf.String(), problems
+}
+
+// allowedHTML returns the lower case names in the config list key, or in
+// defaults if it is empty, as a set.
+func (t *Translator) allowedHTML(key string, defaults []string) map[string]bool {
+	names := cast.ToStringSlice(t.cfg.Get(key))
+	if len(names) == 0 {
+		names = defaults
+	}
+	allowed := make(map[string]bool)
+	for _, name := range names {
+		allowed[strings.ToLower(name)] = true
+	}
+	return allowed
+}
+
+// safeURL reports whether u, a decoded attribute value, is a relative URL or
+// an absolute one with a safe scheme, e.g. not "javascript:". Like
+// browsers, it ignores the tabs and newlines in u, and the spaces and
+// control characters around it.
+func safeURL(u string) bool {
+	u = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, u)
+	u = strings.TrimFunc(u, func(r rune) bool { return r <= ' ' })
+
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' || !validURLScheme(u[:i]) {
+		// Relative, e.g. "/terms" or "{{ .URL }}", checked once executed.
+		return true
+	}
+	return safeURLSchemes[strings.ToLower(u[:i])]
+}
+
+// validURLScheme reports whether s is a valid URL scheme, else a URL with a
+// colon before any slash is relative.
+func validURLScheme(s string) bool {
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// escapeHTMLValue escapes v, a value inserted into an HTML translation, so
+// it is text, also in an attribute. Values of type template.HTML are known
+// to be safe, and inserted as is.
+func escapeHTMLValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if h, ok := v.(template.HTML); ok {
+		return h
+	}
+
+	// Pointers are printed as what they point to, as in a template.
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if _, ok := rv.Interface().(fmt.Stringer); ok {
+			break
+		}
+		if _, ok := rv.Interface().(error); ok {
+			break
+		}
+		rv = rv.Elem()
+	}
+	return template.HTMLEscapeString(fmt.Sprint(rv.Interface()))
+}
+
+// The keys of the bundles in htmlTranslations. A variant or environment
+// override of a translation has its own format, e.g. a plain variant of an
+// HTML translation.
+const baseBundleKey = ""
+
+func variantBundleKey(flags []string) string {
+	flags = append([]string(nil), flags...)
+	sort.Strings(flags)
+	return "flags:" + strings.Join(flags, ",")
+}
+
+func environmentBundleKey(env string) string {
+	return "environment:" + env
+}
+
+// isHTML reports whether the translation of id into lang in the bundle with
+// the given key has the HTML format.
+func (t *Translator) isHTML(bundleKey, lang, id string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.htmlTranslations[bundleKey][lang][id]
+}
+
+func (t *Translator) setHTML(bundleKey, lang, id string, isHTML bool) {
+	if !isHTML {
+		delete(t.htmlTranslations[bundleKey][lang], id)
+		return
+	}
+	if t.htmlTranslations == nil {
+		t.htmlTranslations = make(map[string]map[string]map[string]bool)
+	}
+	if t.htmlTranslations[bundleKey] == nil {
+		t.htmlTranslations[bundleKey] = make(map[string]map[string]bool)
+	}
+	if t.htmlTranslations[bundleKey][lang] == nil {
+		t.htmlTranslations[bundleKey][lang] = make(map[string]bool)
+	}
+	t.htmlTranslations[bundleKey][lang][id] = true
+}
+
+// checkHTMLEntry checks the HTML in the translation in entry, in place. The
+// disallowed HTML is stripped, with a warning, if disallowedTranslationHTML
+// is "strip", else it is an error.
+func (t *Translator) checkHTMLEntry(filename string, entry map[string]interface{}) error {
+	var problems []string
+	check := func(src interface{}) interface{} {
+		s, ok := src.(string)
+		if !ok {
+			return src
+		}
+		checked, p := t.checkHTML(s)
+		problems = append(problems, p...)
+		return checked
+	}
+
+	switch v := entry["translation"].(type) {
+	case string:
+		entry["translation"] = check(v)
+	case map[string]interface{}, map[interface{}]interface{}:
+		forms := make(map[string]interface{})
+		for category, src := range toStringMap(v) {
+			forms[category] = check(src)
+		}
+		entry["translation"] = forms
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	id := cast.ToString(entry["id"])
+	if t.cfg.GetString("disallowedTranslationHTML") == "strip" {
+		t.logger.WARN.Printf("Stripped disallowed HTML from translation %q in %q: %s", id, filename, strings.Join(problems, ", "))
+		return nil
+	}
+	return fmt.Errorf("Disallowed HTML in translation %q in %q: %s", id, filename, strings.Join(problems, ", "))
+}
@@ -0,0 +1,212 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/stretchr/testify/require"
+)
+
+var htmlTestFile = testFile{"en.yaml", `- id: "terms"
+  format: html
+  translation: "Read the <a href=\"/terms\">terms</a><script>alert('x')</script>, <strong>{{ .Name }}</strong>!"
+- id: "steps"
+  format: html
+  translation:
+    one: "One <em>step</em>"
+    other: "{{ .Count }} <em onclick=\"evil()\">steps</em>"
+- id: "text"
+  translation: "<script>Not checked</script>"
+`}
+
+func TestHTMLTranslationError(t *testing.T) {
+	tr := newTranslator(bundle.New(), newTestConfig(), newTestTranslator().logger)
+
+	err := tr.addTranslationFile(htmlTestFile.name, []byte(htmlTestFile.content))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Disallowed HTML in translation "terms" in "en.yaml": <script>`)
+
+	err = tr.addTranslationFile("en.yaml", []byte("- id: \"link\"\n  format: html\n  translation: \"<a href='javascript:evil()'>link</a>\""))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsafe URL in <a>")
+}
+
+func TestHTMLTranslationStrip(t *testing.T) {
+	v := newTestConfig()
+	v.Set("disallowedTranslationHTML", "strip")
+	tr := newTranslatorFromFiles(t, v, htmlTestFile)
+
+	f := tr.Func("en")
+	require.Equal(t, `Read the <a href="/terms">terms</a>, <strong>Ann</strong>!`, f("terms", map[string]interface{}{"Name": "Ann"}))
+	require.Equal(t, "One <em>step</em>", f("steps", 1))
+	require.Equal(t, "2 <em>steps</em>", f("steps", 2))
+	require.Equal(t, "<script>Not checked</script>", f("text"))
+
+	v.Set("htmlTranslationTags", []string{"strong"})
+	tr = newTranslatorFromFiles(t, v, htmlTestFile)
+	require.Equal(t, `Read the terms, <strong>Ann</strong>!`, tr.Func("en")("terms", map[string]interface{}{"Name": "Ann"}))
+}
+
+func TestCheckHTML(t *testing.T) {
+	tr := newTestTranslator()
+
+	for i, test := range []struct {
+		src      string
+		expected string
+		problems int
+	}{
+		{"Plain text", "Plain text", 0},
+		{"<b>Bold</b> and <br/>", "<b>Bold</b> and <br/>", 0},
+		{"<SCRIPT src=x></SCRIPT>", "", 1},
+		{"<style>p { color: red }</style>Text", "Text", 1},
+		{"<img src=x onerror=alert(1)>", "", 1},
+		{"<a href=\"/x\" onmouseover=\"y\">x</a>", `<a href="/x">x</a>`, 1},
+		{"<iframe src=\"x\"><script>alert(1)</script></iframe>Text", "Text", 1},
+		{"<a/onclick=alert(1)>x</a>", "<a>x</a>", 1},
+		{"<a href=\"jav&#x61;script:alert(1)\">x</a>", "<a>x</a>", 1},
+		{"<a href=\"java\tscript:alert(1)\">x</a>", "<a>x</a>", 1},
+		{"<a href=\" \x01JavaScript:alert(1)\">x</a>", "<a>x</a>", 1},
+		{"<a href=\"https://gohugo.io/\" title=\"Hugo\">x</a>", "<a href=\"https://gohugo.io/\" title=\"Hugo\">x</a>", 0},
+		{"<a href=\"{{ .URL }}\">{{ .Name }}</a>", "<a href=\"{{ .URL }}\">{{ .Name }}</a>", 0},
+		{"<a href=\"/x:y\" style=\"color: red\">x</a>", `<a href="/x:y">x</a>`, 1},
+		{"<b>x</b><!-- <script>alert(1)</script> -->", "<b>x</b>", 1},
+	} {
+		checked, problems := tr.checkHTML(test.src)
+		require.Equal(t, test.expected, checked, fmt.Sprintf("[%d] %s", i, test.src))
+		require.Len(t, problems, test.problems, fmt.Sprintf("[%d] %s", i, test.src))
+	}
+}
+
+func TestHTMLTranslationInsertedValues(t *testing.T) {
+	var buf bytes.Buffer
+	tr := newTranslator(bundle.New(), newTestConfig(), jww.NewNotepad(jww.LevelWarn, jww.LevelError, &buf, ioutil.Discard, "", 0))
+	require.NoError(t, tr.addTranslationFile("en.yaml", []byte(`- id: "greeting"
+  format: html
+  translation: "Hello, <strong>{{ .Name }}</strong>!"
+- id: "link"
+  format: html
+  translation: "<a href=\"{{ .URL }}\" title=\"{{ .Title }}\">{{ .Title }}</a>"
+- id: "text"
+  translation: "Hello, {{ .Name }}!"
+`)))
+	tr.initFuncs()
+	f := tr.Func("en")
+
+	require.Equal(t, "Hello, <strong>&lt;script&gt;alert(1)&lt;/script&gt;</strong>!", f("greeting", map[string]interface{}{"Name": "<script>alert(1)</script>"}))
+	require.Equal(t, "Hello, <strong><em>Ann</em></strong>!", f("greeting", map[string]interface{}{"Name": template.HTML("<em>Ann</em>")}))
+	require.Equal(t, `<a href="/about" title="&#34; onclick=&#34;alert(1)">&#34; onclick=&#34;alert(1)</a>`, f("link", map[string]interface{}{"URL": "/about", "Title": `" onclick="alert(1)`}))
+	require.Empty(t, buf.String())
+
+	// Escaping does not make a javascript: URL safe, so the output is checked too.
+	require.Equal(t, `<a title="About">About</a>`, f("link", map[string]interface{}{"URL": "javascript:alert(1)", "Title": "About"}))
+	require.Contains(t, buf.String(), `Stripped disallowed HTML from translation "link" in language "en": unsafe URL in <a>`)
+
+	// Other translations are not HTML.
+	require.Equal(t, "Hello, <b>Ann</b>!", f("text", map[string]interface{}{"Name": "<b>Ann</b>"}))
+}
+
+func TestHTMLTranslationFuncHTML(t *testing.T) {
+	v := newTestConfig()
+	v.Set("wrapFallbackTranslations", true)
+	tr := newTranslatorFromFiles(t, v, testFile{"en.yaml", `- id: "greeting"
+  format: html
+  translation: "Hello, <strong>{{ .Name }}</strong>!"
+- id: "text"
+  translation: "Hello, <strong>{{ .Name }}</strong>!"
+`})
+	data := map[string]interface{}{"Name": "<b>Ann</b>"}
+
+	f := tr.FuncHTML("en")
+	require.Equal(t, template.HTML("Hello, <strong>&lt;b&gt;Ann&lt;/b&gt;</strong>!"), f("greeting", data))
+	require.Equal(t, template.HTML("Hello, &lt;strong&gt;&lt;b&gt;Ann&lt;/b&gt;&lt;/strong&gt;!"), f("text", data))
+
+	require.Equal(t, template.HTML(`<span lang="en">Hello, <strong>&lt;b&gt;Ann&lt;/b&gt;</strong>!</span>`), tr.FuncHTML("de")("greeting", data))
+}
+
+func TestHTMLTranslationVariantFormats(t *testing.T) {
+	files := []testFile{{"en.yaml", `- id: "notice"
+  format: html
+  translation: "Read the <strong>{{ .Name }}</strong>"
+- id: "notice"
+  flags: [beta]
+  translation: "Read the <strong>{{ .Name }}</strong> beta"
+- id: "notice"
+  environment: staging
+  translation: "Read the <strong>{{ .Name }}</strong> draft"
+- id: "banner"
+  translation: "Welcome, {{ .Name }}"
+- id: "banner"
+  flags: [beta]
+  format: html
+  translation: "Welcome to the <strong>beta</strong>, {{ .Name }}"
+- id: "banner"
+  environment: staging
+  format: html
+  translation: "Welcome to <em>staging</em>, {{ .Name }}"
+`}}
+	data := map[string]interface{}{"Name": "<b>"}
+
+	for i, test := range []struct {
+		flags        []string
+		environment  string
+		notice       template.HTML
+		banner       template.HTML
+		noticeString string
+		bannerString string
+	}{
+		{nil, "",
+			"Read the <strong>&lt;b&gt;</strong>",
+			"Welcome, &lt;b&gt;",
+			"Read the <strong>&lt;b&gt;</strong>",
+			"Welcome, <b>"},
+		// A plain variant of an HTML translation, and an HTML variant of
+		// a plain one.
+		{[]string{"beta"}, "",
+			"Read the &lt;strong&gt;&lt;b&gt;&lt;/strong&gt; beta",
+			"Welcome to the <strong>beta</strong>, &lt;b&gt;",
+			"Read the <strong><b></strong> beta",
+			"Welcome to the <strong>beta</strong>, &lt;b&gt;"},
+		{nil, "staging",
+			"Read the &lt;strong&gt;&lt;b&gt;&lt;/strong&gt; draft",
+			"Welcome to <em>staging</em>, &lt;b&gt;",
+			"Read the <strong><b></strong> draft",
+			"Welcome to <em>staging</em>, &lt;b&gt;"},
+	} {
+		v := newTestConfig()
+		v.Set("translationFlags", test.flags)
+		v.Set("translationEnvironment", test.environment)
+		tr := newTranslatorFromFiles(t, v, files...)
+
+		require.Equal(t, test.notice, tr.FuncHTML("en")("notice", data), fmt.Sprintf("[%d] notice", i))
+		require.Equal(t, test.banner, tr.FuncHTML("en")("banner", data), fmt.Sprintf("[%d] banner", i))
+		require.Equal(t, test.noticeString, tr.Func("en")("notice", data), fmt.Sprintf("[%d] notice", i))
+		require.Equal(t, test.bannerString, tr.Func("en")("banner", data), fmt.Sprintf("[%d] banner", i))
+
+		// The formats survive a snapshot and combining.
+		s := tr.Snapshot()
+		tr.Restore(s)
+		require.Equal(t, test.banner, tr.FuncHTML("en")("banner", data), fmt.Sprintf("[%d] restored", i))
+		c, err := CombineTranslators(tr, newTranslatorFromFiles(t, v, files...))
+		require.NoError(t, err)
+		require.Equal(t, test.notice, c.FuncHTML("en")("notice", data), fmt.Sprintf("[%d] combined", i))
+		require.Equal(t, test.banner, c.FuncHTML("en")("banner", data), fmt.Sprintf("[%d] combined", i))
+	}
+}
@@ -14,7 +14,17 @@
 package i18n
 
 import (
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	gotemplate "text/template"
+	"time"
+
 	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/nicksnyder/go-i18n/i18n/language"
+	"github.com/nicksnyder/go-i18n/i18n/translation"
+	"github.com/spf13/cast"
 	"github.com/srcclr/hugo/config"
 	"github.com/srcclr/hugo/helpers"
 	jww "github.com/spf13/jwalterweatherman"
@@ -22,30 +32,178 @@ import (
 
 var (
 	i18nWarningLogger = helpers.NewDistinctFeedbackLogger()
+
+	// pluralCategories are all the CLDR plural categories, in their canonical order.
+	pluralCategories = []language.Plural{
+		language.Zero, language.One, language.Two,
+		language.Few, language.Many, language.Other,
+	}
 )
 
+// MissingBehavior determines what is returned for a missing translation.
+type MissingBehavior string
+
+const (
+	// MissingDefault uses the behavior configured for the site.
+	MissingDefault MissingBehavior = ""
+
+	// MissingPlaceholder returns "[i18n] " followed by the translation id,
+	// without trying the default content language first.
+	MissingPlaceholder MissingBehavior = "placeholder"
+
+	// MissingEmpty returns an empty string.
+	MissingEmpty MissingBehavior = "empty"
+
+	// MissingID returns the translation id.
+	MissingID MissingBehavior = "id"
+
+	// MissingError returns an error.
+	MissingError MissingBehavior = "error"
+)
+
+// TranslateOptions holds the options for a single Translate call.
+type TranslateOptions struct {
+	// Count selects the plural form, if set.
+	Count interface{}
+
+	// Data is the data passed to the translation template.
+	Data interface{}
+
+	// OnMissing overrides the configured missing translation behavior.
+	OnMissing MissingBehavior
+
+	// DefaultFunc, if set, returns the translation to use if it is missing
+	// in both the given and the default content language. It is only called
+	// then, and makes OnMissing unused.
+	DefaultFunc func() string
+
+	// Kind, if set, is the kind of the page translated for, e.g. "home".
+	// The id qualified with it, e.g. "home.title", is then tried before the
+	// bare id in every language.
+	Kind string
+}
+
+func (o TranslateOptions) args() []interface{} {
+	if o.Count != nil {
+		return []interface{}{o.Count, o.Data}
+	}
+	return []interface{}{o.Data}
+}
+
 // Translator handles i18n translations.
 type Translator struct {
+	bundle *bundle.Bundle
+	cfg    config.Provider
+	logger *jww.Notepad
+
+	// The languages to serve, all if nil.
+	enabledLanguages map[string]bool
+
+	// Translation variants and the flags that enable them.
+	flagged     []*flaggedBundle
+	activeFlags map[string]bool
+
+	// Translations overridden by environment, and the current environment,
+	// guarded by mu.
+	environmentBundles map[string]*bundle.Bundle
+	environment        string
+
+	mu             sync.RWMutex
 	translateFuncs map[string]bundle.TranslateFunc
-	cfg            config.Provider
-	logger         *jww.Notepad
+
+	// Languages requested but not loaded, to warn about them only once.
+	missingLanguages map[string]bool
+
+	// Collects translations missing in the default content language, if set.
+	missingWriter *missingWriter
+
+	// Formats numbers, currency amounts and dates, guarded by mu.
+	formatter Formatter
+
+	// The built-in locale data loaded so far.
+	locales *localeCache
+
+	// Translations in the ICU MessageFormat syntax, by language and id.
+	icuMessages map[string]map[string]*icuMessage
+
+	// Translations picked by ranges of counts, by language and id.
+	bucketMessages map[string]map[string]*bucketMessage
+
+	// The ids of the translations with the HTML format, by bundle key and
+	// language, see baseBundleKey.
+	htmlTranslations map[string]map[string]map[string]bool
+
+	// Deprecated translation ids and what replaces them.
+	deprecations map[string]*deprecation
+	now          func() time.Time
+
+	// Wraps resolving every translation, the first added outermost,
+	// guarded by mu.
+	middleware []Middleware
+
+	// Display names of languages set by the user, guarded by mu.
+	displayNames map[string]string
+
+	// Template funcs added by language, all languages for "".
+	funcsMu sync.RWMutex
+	funcs   map[string]gotemplate.FuncMap
+
+	// The translation ids calling each template func, if recorded.
+	funcUsageMu sync.Mutex
+	funcUsage   map[string]map[string]bool
+
+	// The template errors collected, if collectTemplateErrors is set, by
+	// language and id.
+	templateErrorsMu sync.Mutex
+	templateErrors   map[string]*TemplateError
+
+	// Parsed translation templates, by language and source.
+	templatesMu sync.Mutex
+	templates   map[string]*gotemplate.Template
 }
 
 // NewTranslator creates a new Translator for the given language bundle and configuration.
-func NewTranslator(b *bundle.Bundle, cfg config.Provider, logger *jww.Notepad) Translator {
-	t := Translator{cfg: cfg, logger: logger, translateFuncs: make(map[string]bundle.TranslateFunc)}
-	t.initFuncs(b)
+func NewTranslator(b *bundle.Bundle, cfg config.Provider, logger *jww.Notepad) *Translator {
+	t := newTranslator(bundle.New(), cfg, logger)
+	t.bundle = t.escapeBundle(b)
+	t.initFuncs()
+	return t
+}
+
+func newTranslator(b *bundle.Bundle, cfg config.Provider, logger *jww.Notepad) *Translator {
+	locales := newLocaleCache(cfg.GetString("formattingFallbackLocale"), logger)
+	t := &Translator{
+		bundle:           b,
+		cfg:              cfg,
+		logger:           logger,
+		activeFlags:      make(map[string]bool),
+		translateFuncs:   make(map[string]bundle.TranslateFunc),
+		missingLanguages: make(map[string]bool),
+		environment:      cfg.GetString("translationEnvironment"),
+		formatter:        defaultFormatter{locales: locales},
+		locales:          locales,
+		now:              time.Now,
+	}
+	for _, flag := range cast.ToStringSlice(cfg.Get("translationFlags")) {
+		t.activeFlags[flag] = true
+	}
+	if enabled := cast.ToStringSlice(cfg.Get("enabledLanguages")); len(enabled) > 0 {
+		t.enabledLanguages = make(map[string]bool)
+		for _, lang := range enabled {
+			t.enabledLanguages[lang] = true
+		}
+	}
 	return t
 }
 
 // Func gets the translate func for the given language, or for the default
 // configured language if not found.
-func (t Translator) Func(lang string) bundle.TranslateFunc {
-	if f, ok := t.translateFuncs[lang]; ok {
+func (t *Translator) Func(lang string) bundle.TranslateFunc {
+	if f, ok := t.langFunc(lang); ok {
 		return f
 	}
-	t.logger.WARN.Printf("Translation func for language %v not found, use default.", lang)
-	if f, ok := t.translateFuncs[t.cfg.GetString("defaultContentLanguage")]; ok {
+	t.warnMissingLanguage(lang)
+	if f, ok := t.langFunc(t.cfg.GetString("defaultContentLanguage")); ok {
 		return f
 	}
 	t.logger.WARN.Println("i18n not initialized, check that you have language file (in i18n) that matches the site language or the default language.")
@@ -55,42 +213,451 @@ func (t Translator) Func(lang string) bundle.TranslateFunc {
 
 }
 
-func (t Translator) initFuncs(bndl *bundle.Bundle) {
-	defaultContentLanguage := t.cfg.GetString("defaultContentLanguage")
-	var (
-		defaultT bundle.TranslateFunc
-		err      error
-	)
+func (t *Translator) langFunc(lang string) (bundle.TranslateFunc, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	f, ok := t.translateFuncs[lang]
+	return f, ok
+}
+
+// hasLanguage reports whether there are translations loaded for lang.
+func (t *Translator) hasLanguage(lang string) bool {
+	_, ok := t.langFunc(lang)
+	return ok
+}
 
-	defaultT, err = bndl.Tfunc(defaultContentLanguage)
+// warnMissingLanguage logs, once per language, that no translations are
+// loaded for lang. This is logged instead of
+// a warning for every translation looked up in that language.
+func (t *Translator) warnMissingLanguage(lang string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.missingLanguages[lang] {
+		return
+	}
+	t.missingLanguages[lang] = true
+	t.logger.WARN.Printf("No translations found for language %q, use default content language.", lang)
+}
 
+// Translate translates translationID into lang using the given options.
+// An error is only returned if the translation is missing and the missing
+// translation behavior is MissingError.
+func (t *Translator) Translate(lang, translationID string, opts TranslateOptions) (string, error) {
+	ropts := resolveOptions{onMissing: opts.OnMissing, kind: opts.Kind}
+	if opts.DefaultFunc == nil {
+		translated, _, err := t.resolve(lang, translationID, ropts, opts.args()...)
+		return translated, err
+	}
+
+	ropts.onMissing = MissingEmpty
+	translated, translatedLang, err := t.resolve(lang, translationID, ropts, opts.args()...)
+	if err == nil && translatedLang == "" {
+		return opts.DefaultFunc(), nil
+	}
+	return translated, err
+}
+
+// TranslateCounted translates translationID into lang, like the translate
+// func called with args, and also returns the number of values inserted
+// into the translation, e.g. 2 for "{{ .Name }} wrote {{ .WordCount }} words".
+// Values inserted in a range are counted once per iteration.
+func (t *Translator) TranslateCounted(lang, translationID string, args interface{}) (string, int) {
+	var substitutions int
+
+	var targs []interface{}
+	if args != nil {
+		targs = []interface{}{args}
+	}
+
+	translated, _, err := t.resolve(lang, translationID, resolveOptions{substitutions: &substitutions}, targs...)
 	if err != nil {
+		t.logger.ERROR.Println(err)
+	}
+	return translated, substitutions
+}
+
+// FuncHTML is like Func, but the translations are returned as HTML, escaped
+// unless they have the HTML format, which are checked and have the values
+// inserted escaped instead. With wrapFallbackTranslations enabled,
+// translations taken from another language than lang are wrapped in a span
+// with a lang attribute, so screen readers and browsers know the text is in
+// another language.
+func (t *Translator) FuncHTML(lang string) func(translationID string, args ...interface{}) template.HTML {
+	wrap := t.cfg.GetBool("wrapFallbackTranslations")
+	return func(translationID string, args ...interface{}) template.HTML {
+		var isHTML bool
+		translated, translatedLang, err := t.resolve(lang, translationID, resolveOptions{html: &isHTML}, args...)
+		if err != nil {
+			t.logger.ERROR.Println(err)
+		}
+
+		escaped := translated
+		if !isHTML || translatedLang == "" {
+			escaped = template.HTMLEscapeString(translated)
+		}
+		if wrap && translatedLang != "" && translatedLang != lang {
+			return template.HTML(fmt.Sprintf(`<span lang="%s">%s</span>`, template.HTMLEscapeString(translatedLang), escaped))
+		}
+		return template.HTML(escaped)
+	}
+}
+
+// Raw returns the translation template stored for the given language and id,
+// without executing it. For plural translations the template of the "other"
+// category is returned, and for bucket messages the "other" text, or else
+// the one for the highest counts.
+func (t *Translator) Raw(lang, id string) (string, bool) {
+	t.mu.RLock()
+	msg := t.icuMessages[lang][id]
+	buckets := t.bucketMessages[lang][id]
+	t.mu.RUnlock()
+	if msg != nil {
+		return msg.src, true
+	}
+	if buckets != nil {
+		templates := buckets.templates()
+		return templates[len(templates)-1], true
+	}
+
+	tr, found := t.bundle.Translations()[lang][id]
+	if !found {
+		return "", false
+	}
+	return templateSource(tr, language.Other)
+}
+
+// AddLanguage parses the translations in content, in YAML or JSON, and adds
+// them to the translations for lang, merging them with any already loaded.
+func (t *Translator) AddLanguage(lang string, content []byte) error {
+	return t.addLanguage(lang, lang+".yaml", content)
+}
+
+// addLanguage adds the translations for lang in content, in the format
+// given by the extension of filename.
+func (t *Translator) addLanguage(lang, filename string, content []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.addTranslationFile(filename, content); err != nil {
+		return fmt.Errorf("Failed to add translations for language %q: %s", lang, err)
+	}
+
+	// Translate funcs are looked up by the bundle's language tags.
+	for _, tag := range t.bundle.LanguageTags() {
+		if _, found := t.translateFuncs[tag]; !found && t.languageEnabled(tag) {
+			t.addFunc(tag)
+		}
+	}
+
+	return nil
+}
+
+func (t *Translator) initFuncs() {
+	defaultContentLanguage := t.cfg.GetString("defaultContentLanguage")
+
+	if _, err := t.bundle.Tfunc(defaultContentLanguage); err != nil {
 		jww.WARN.Printf("No translation bundle found for default language %q", defaultContentLanguage)
 	}
 
-	enableMissingTranslationPlaceholders := t.cfg.GetBool("enableMissingTranslationPlaceholders")
-	for _, lang := range bndl.LanguageTags() {
-		currentLang := lang
+	for _, lang := range t.bundle.LanguageTags() {
+		if t.languageEnabled(lang) {
+			t.addFunc(lang)
+		}
+	}
+}
 
-		t.translateFuncs[currentLang] = func(translationID string, args ...interface{}) string {
-			tFunc, err := bndl.Tfunc(currentLang)
-			if err != nil {
-				jww.WARN.Printf("could not load translations for language %q (%s), will use default content language.\n", lang, err)
-			} else if translated := tFunc(translationID, args...); translated != translationID {
-				return translated
-			}
-			if t.cfg.GetBool("logI18nWarnings") {
-				i18nWarningLogger.Printf("i18n|MISSING_TRANSLATION|%s|%s", currentLang, translationID)
-			}
-			if enableMissingTranslationPlaceholders {
-				return "[i18n] " + translationID
+// languageEnabled reports whether translations in lang may be served. The
+// default content language is always enabled, as everything falls back to it.
+func (t *Translator) languageEnabled(lang string) bool {
+	return t.enabledLanguages == nil || t.enabledLanguages[lang] || lang == t.cfg.GetString("defaultContentLanguage")
+}
+
+func (t *Translator) addFunc(lang string) {
+	t.translateFuncs[lang] = func(translationID string, args ...interface{}) string {
+		translated, err := t.translate(lang, translationID, MissingDefault, args...)
+		if err != nil {
+			t.logger.ERROR.Println(err)
+		}
+		return translated
+	}
+}
+
+// resolveOptions are the options for resolving a single translation.
+type resolveOptions struct {
+	onMissing MissingBehavior
+
+	// If set, incremented for every value inserted into the translation.
+	substitutions *int
+
+	// If set, the page kind to qualify the id with, see TranslateOptions.
+	kind string
+
+	// If set, receives the first error executing a translation template.
+	execErr *error
+
+	// If set, errors executing translation templates are neither logged
+	// nor collected, and template func usage is not recorded, e.g. when
+	// benchmarking.
+	quiet bool
+
+	// If set, receives whether the translation found has the HTML format.
+	html *bool
+}
+
+// translate translates translationID into lang. If it is missing in lang,
+// its fallback languages are tried, ending with the default content
+// language, unless onMissing (or the configured behavior it defaults to) is
+// MissingPlaceholder.
+func (t *Translator) translate(lang, translationID string, onMissing MissingBehavior, args ...interface{}) (string, error) {
+	translated, _, err := t.resolve(lang, translationID, resolveOptions{onMissing: onMissing}, args...)
+	return translated, err
+}
+
+// resolve is like translate, but also returns the language the translation
+// was taken from, or an empty string if it is missing. Deprecated ids are
+// resolved to the id replacing them. The middleware added wraps it.
+func (t *Translator) resolve(lang, translationID string, opts resolveOptions, args ...interface{}) (string, string, error) {
+	t.mu.RLock()
+	middleware := t.middleware
+	t.mu.RUnlock()
+
+	if len(middleware) == 0 {
+		return t.resolveTranslation(lang, translationID, opts, args...)
+	}
+
+	f := ResolveFunc(func(lang, translationID string, args ...interface{}) (string, string, error) {
+		return t.resolveTranslation(lang, translationID, opts, args...)
+	})
+	for i := len(middleware) - 1; i >= 0; i-- {
+		f = middleware[i](f)
+	}
+	return f(lang, translationID, args...)
+}
+
+// resolveTranslation is resolve without the middleware.
+func (t *Translator) resolveTranslation(lang, translationID string, opts resolveOptions, args ...interface{}) (string, string, error) {
+	translationID, err := t.redirect(translationID)
+	if err != nil {
+		return "", "", err
+	}
+
+	args = t.countArgs(args)
+
+	if translated, found := t.lookup(lang, translationID, opts, args...); found {
+		return t.filterOutput(lang, translated), lang, nil
+	}
+
+	if t.cfg.GetBool("logI18nWarnings") {
+		i18nWarningLogger.Printf("i18n|MISSING_TRANSLATION|%s|%s", lang, translationID)
+	}
+
+	onMissing := opts.onMissing
+	if onMissing == MissingDefault {
+		onMissing = t.missingBehavior()
+	}
+
+	defaultContentLanguage := t.cfg.GetString("defaultContentLanguage")
+	var (
+		defaultTranslated string
+		inDefault         bool
+	)
+	if t.missingWriter != nil {
+		defaultTranslated, inDefault = t.lookup(defaultContentLanguage, translationID, opts, args...)
+		if !inDefault {
+			if err := t.missingWriter.add(translationID); err != nil {
+				t.logger.ERROR.Printf("Failed to add missing translation %q to %q: %s", translationID, t.missingWriter.filename, err)
 			}
-			if defaultT != nil {
-				if translated := defaultT(translationID, args...); translated != translationID {
-					return translated
-				}
+		}
+	}
+
+	if onMissing == MissingPlaceholder {
+		return "[i18n] " + translationID, "", nil
+	}
+
+	for _, l := range t.fallbackLanguages(lang) {
+		translated, found := defaultTranslated, inDefault
+		if l != defaultContentLanguage || t.missingWriter == nil {
+			translated, found = t.lookup(l, translationID, opts, args...)
+		}
+		if found {
+			return t.filterOutput(l, translated), l, nil
+		}
+	}
+
+	switch onMissing {
+	case MissingID:
+		return translationID, "", nil
+	case MissingError:
+		return "", "", fmt.Errorf("Missing translation for %q in language %q", translationID, lang)
+	}
+
+	return "", "", nil
+}
+
+// fallbackLanguages returns the languages to try, in order, if a translation
+// is missing in lang: those listed for it in translationFallbacks, then
+// theirs, and so on, and finally the default content language. If
+// maxFallbackDepth is set, no more languages than that are tried.
+func (t *Translator) fallbackLanguages(lang string) []string {
+	fallbacks := t.cfg.GetStringMap("translationFallbacks")
+	defaultContentLanguage := t.cfg.GetString("defaultContentLanguage")
+
+	var chain []string
+	seen := map[string]bool{lang: true}
+	queue := []string{lang}
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+		for _, f := range cast.ToStringSlice(fallbacks[strings.ToLower(l)]) {
+			if !seen[f] {
+				seen[f] = true
+				chain = append(chain, f)
+				queue = append(queue, f)
 			}
-			return ""
 		}
 	}
+	if !seen[defaultContentLanguage] {
+		chain = append(chain, defaultContentLanguage)
+	}
+
+	if max := t.cfg.GetInt("maxFallbackDepth"); max > 0 && len(chain) > max {
+		helpers.DistinctWarnLog.Printf("Fallback languages for language %q truncated to %d of %d: %s", lang, max, len(chain), strings.Join(chain[max:], ", "))
+		chain = chain[:max]
+	}
+
+	return chain
+}
+
+// lookup translates translationID into lang, reporting whether a translation
+// was found. The id qualified with the page kind in opts, if any, the
+// overrides for the environment and variants enabled by the active
+// translation flags are preferred. Nothing is found in languages that are
+// not enabled.
+func (t *Translator) lookup(lang, translationID string, opts resolveOptions, args ...interface{}) (string, bool) {
+	if !t.languageEnabled(lang) {
+		return "", false
+	}
+
+	if opts.kind != "" {
+		kindOpts := opts
+		kindOpts.kind = ""
+		if translated, found := t.lookup(lang, opts.kind+"."+translationID, kindOpts, args...); found {
+			return translated, true
+		}
+	}
+
+	t.mu.RLock()
+	envBundle := t.environmentBundles[t.environment]
+	flagged := t.flagged
+	msg := t.icuMessages[lang][translationID]
+	buckets := t.bucketMessages[lang][translationID]
+	t.mu.RUnlock()
+
+	if envBundle != nil {
+		if translated, found := t.lookupIn(envBundle, environmentBundleKey(t.environment), lang, translationID, opts, args); found {
+			return translated, true
+		}
+	}
+
+	for _, fb := range flagged {
+		if !t.flagsActive(fb.flags) {
+			continue
+		}
+		if translated, found := t.lookupIn(fb.bundle, variantBundleKey(fb.flags), lang, translationID, opts, args); found {
+			return translated, true
+		}
+	}
+
+	if buckets != nil {
+		return t.lookupBuckets(buckets, lang, translationID, opts, args)
+	}
+
+	if msg != nil {
+		return t.formatICU(msg, lang, translationID, opts, args), true
+	}
+
+	tFunc, err := t.bundle.Tfunc(lang)
+	if err != nil {
+		t.warnMissingLanguage(lang)
+		return "", false
+	}
+	if src := tFunc(translationID, args...); src != translationID {
+		return t.executeFound(lang, translationID, src, t.isHTML(baseBundleKey, lang, translationID), opts, args), true
+	}
+	return "", false
+}
+
+// lookupIn translates translationID into lang using the translations in b,
+// the bundle with the given key, only, if any.
+func (t *Translator) lookupIn(b *bundle.Bundle, bundleKey, lang, translationID string, opts resolveOptions, args []interface{}) (string, bool) {
+	tFunc, err := b.Tfunc(lang)
+	if err != nil {
+		return "", false
+	}
+	if src := tFunc(translationID, args...); src != translationID {
+		return t.executeFound(lang, translationID, src, t.isHTML(bundleKey, lang, translationID), opts, args), true
+	}
+	return "", false
+}
+
+// executeFound executes src, the template found for translationID, logging
+// any error, and collecting it if collectTemplateErrors is set, unless opts
+// is quiet. If isHTML is set, src is an HTML translation.
+func (t *Translator) executeFound(lang, translationID, src string, isHTML bool, opts resolveOptions, args []interface{}) string {
+	if opts.html != nil {
+		*opts.html = isHTML
+	}
+	translated, err := t.execute(lang, translationID, src, isHTML, opts, args)
+	if err != nil {
+		terr := &TemplateError{Lang: lang, ID: translationID, Err: err}
+		if opts.execErr != nil && *opts.execErr == nil {
+			*opts.execErr = terr
+		}
+		if opts.quiet {
+			return translated
+		}
+		t.logger.ERROR.Println(terr)
+		if t.cfg.GetBool("collectTemplateErrors") {
+			t.addTemplateError(terr)
+		}
+	}
+	return translated
+}
+
+func (t *Translator) flagsActive(flags []string) bool {
+	for _, flag := range flags {
+		if !t.activeFlags[flag] {
+			return false
+		}
+	}
+	return true
+}
+
+// missingBehavior returns the globally configured missing translation behavior.
+func (t *Translator) missingBehavior() MissingBehavior {
+	if behavior := MissingBehavior(t.cfg.GetString("missingTranslationBehavior")); behavior != MissingDefault {
+		return behavior
+	}
+	if t.cfg.GetBool("enableMissingTranslationPlaceholders") {
+		return MissingPlaceholder
+	}
+	return MissingEmpty
+}
+
+// pluralForms returns the template source of every plural category defined
+// for t, or nil if t is not a plural translation.
+func pluralForms(t translation.Translation) map[language.Plural]string {
+	// Single translations return their one template for any category,
+	// plural translations nothing for categories they do not define.
+	if t.Template(language.Invalid) != nil {
+		return nil
+	}
+
+	forms := make(map[language.Plural]string)
+	for _, category := range pluralCategories {
+		if src, found := templateSource(t, category); found {
+			forms[category] = src
+		}
+	}
+	return forms
 }
@@ -14,6 +14,10 @@
 package i18n
 
 import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
 	"testing"
 
 	"io/ioutil"
@@ -112,6 +116,28 @@ var i18nTests = []i18nTest{
 		expected:     "¡Hola, 50 gente!",
 		expectedFlag: "¡Hola, 50 gente!",
 	},
+	// Nested context provided
+	{
+		data: map[string][]byte{
+			"en.yaml": []byte("- id: \"pageTitle\"\n  translation: \"Read {{.Page.Title}} by {{.Page.Author.Name}}\""),
+			"es.yaml": []byte("- id: \"pageTitle\"\n  translation: \"Lee {{.Page.Title}} de {{.Page.Author.Name}}\""),
+		},
+		args: struct {
+			Page struct {
+				Title  string
+				Author *struct{ Name string }
+			}
+		}{
+			Page: struct {
+				Title  string
+				Author *struct{ Name string }
+			}{"Hugo", &struct{ Name string }{"Steve"}},
+		},
+		lang:         "es",
+		id:           "pageTitle",
+		expected:     "Lee Hugo de Steve",
+		expectedFlag: "Lee Hugo de Steve",
+	},
 }
 
 func doTestI18nTranslate(t *testing.T, data map[string][]byte, lang, id string, args interface{}, cfg config.Provider) string {
@@ -153,3 +179,449 @@ func TestI18nTranslate(t *testing.T) {
 		}
 	}
 }
+
+func newTestConfig() *viper.Viper {
+	v := viper.New()
+	v.SetDefault("defaultContentLanguage", "en")
+	return v
+}
+
+// newTestTranslator creates a Translator without any translations.
+func newTestTranslator() *Translator {
+	return NewTranslator(bundle.New(), newTestConfig(), logger)
+}
+
+type testFile struct {
+	name, content string
+}
+
+// newTranslatorFromFiles creates a Translator the same way as the
+// TranslationProvider, loading files in the order given.
+func newTranslatorFromFiles(t *testing.T, cfg config.Provider, files ...testFile) *Translator {
+	translator := newTranslator(bundle.New(), cfg, logger)
+	for _, file := range files {
+		require.NoError(t, translator.addTranslationFile(file.name, []byte(file.content)))
+	}
+	translator.initFuncs()
+	return translator
+}
+
+func TestI18nMergePluralForms(t *testing.T) {
+	v := viper.New()
+	v.SetDefault("defaultContentLanguage", "en")
+
+	// The theme provides the singular form, the project adds the plural one.
+	f := newTranslatorFromFiles(t, v,
+		testFile{"en.yaml", "- id: \"items\"\n  translation:\n    one: \"One item\""},
+		testFile{"en.yaml", "- id: \"items\"\n  translation:\n    other: \"{{.Count}} items\""},
+	).Func("en")
+
+	require.Equal(t, "One item", f("items", 1))
+	require.Equal(t, "3 items", f("items", 3))
+}
+
+func TestI18nPaddedIDs(t *testing.T) {
+	var buf bytes.Buffer
+	v := newTestConfig()
+	tr := newTranslator(bundle.New(), v, jww.NewNotepad(jww.LevelWarn, jww.LevelError, &buf, ioutil.Discard, "", 0))
+
+	padded := []byte(`- id: " hello"
+  translation: "Hello"
+- id: "bye"
+  translation: "Bye"
+`)
+	require.NoError(t, tr.addTranslationFile("en.yaml", padded))
+	tr.initFuncs()
+
+	require.Equal(t, "Hello", tr.Func("en")("hello"))
+	require.Equal(t, 1, strings.Count(buf.String(), "WARN"))
+	require.Contains(t, buf.String(), `Trimmed leading or trailing whitespace from translation id " hello" in "en.yaml"`)
+
+	v.Set("paddedTranslationIDs", "error")
+	err := newTranslator(bundle.New(), v, logger).addTranslationFile("en.yaml", padded)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Translation id " hello" in "en.yaml" has leading or trailing whitespace`)
+}
+
+func TestI18nTranslateNestedWithCount(t *testing.T) {
+	type author struct{ Name string }
+	type page struct {
+		Title  string
+		Author author
+	}
+
+	f := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "comments"
+  translation:
+    one: "One comment on {{.Page.Title}} by {{.Page.Author.Name}}"
+    other: "{{.Count}} comments on {{.Page.Title}} by {{.Page.Author.Name}}"
+`}).Func("en")
+
+	data := map[string]interface{}{"Page": page{"Hugo", author{"Steve"}}}
+
+	require.Equal(t, "One comment on Hugo by Steve", f("comments", 1, data))
+	require.Equal(t, "5 comments on Hugo by Steve", f("comments", 5, data))
+}
+
+func TestI18nTranslateCountFromData(t *testing.T) {
+	type list struct {
+		Count int
+		Total int
+		Name  string
+	}
+
+	files := testFile{"en.yaml", `- id: "items"
+  translation:
+    one: "One item in {{.Name}}"
+    other: "{{.Count}} items in {{.Name}}"
+`}
+
+	f := newTranslatorFromFiles(t, newTestConfig(), files).Func("en")
+	require.Equal(t, "3 items in Inbox", f("items", list{Count: 3, Name: "Inbox"}))
+	require.Equal(t, "One item in Inbox", f("items", &list{Count: 1, Name: "Inbox"}))
+
+	cfg := newTestConfig()
+	cfg.Set("translationCountField", "Total")
+	f = newTranslatorFromFiles(t, cfg, files).Func("en")
+
+	data := map[string]interface{}{"Total": 1, "Name": "Inbox"}
+	require.Equal(t, "3 items in Inbox", f("items", list{Total: 3, Name: "Inbox"}))
+	require.Equal(t, "One item in Inbox", f("items", data))
+	require.NotContains(t, data, "Count")
+	require.Equal(t, "5 items in Inbox", f("items", 5, data))
+}
+
+func TestI18nRaw(t *testing.T) {
+	v := viper.New()
+	v.SetDefault("defaultContentLanguage", "en")
+
+	i18nBundle := bundle.New()
+	require.NoError(t, i18nBundle.ParseTranslationFileBytes("en.yaml",
+		[]byte("- id: \"wordCount\"\n  translation: \"Hello, {{.WordCount}} people!\"")))
+
+	translator := NewTranslator(i18nBundle, v, logger)
+
+	raw, found := translator.Raw("en", "wordCount")
+	require.True(t, found)
+	require.Equal(t, "Hello, {{.WordCount}} people!", raw)
+
+	_, found = translator.Raw("en", "missing")
+	require.False(t, found)
+}
+
+func TestI18nTranslateOnMissing(t *testing.T) {
+	v := viper.New()
+	v.SetDefault("defaultContentLanguage", "en")
+	v.Set("enableMissingTranslationPlaceholders", true)
+
+	i18nBundle := bundle.New()
+	require.NoError(t, i18nBundle.ParseTranslationFileBytes("en.yaml",
+		[]byte("- id: \"hello\"\n  translation: \"Hello, World!\"")))
+
+	translator := NewTranslator(i18nBundle, v, logger)
+
+	for _, test := range []struct {
+		onMissing MissingBehavior
+		expected  string
+	}{
+		{MissingDefault, "[i18n] label"},
+		{MissingPlaceholder, "[i18n] label"},
+		{MissingEmpty, ""},
+		{MissingID, "label"},
+	} {
+		translated, err := translator.Translate("en", "label", TranslateOptions{OnMissing: test.onMissing})
+		require.NoError(t, err)
+		require.Equal(t, test.expected, translated)
+	}
+
+	_, err := translator.Translate("en", "label", TranslateOptions{OnMissing: MissingError})
+	require.Error(t, err)
+
+	translated, err := translator.Translate("en", "hello", TranslateOptions{OnMissing: MissingError})
+	require.NoError(t, err)
+	require.Equal(t, "Hello, World!", translated)
+
+	// The global default still applies to the template func.
+	require.Equal(t, "[i18n] label", translator.Func("en")("label"))
+}
+
+func TestI18nTranslateDefaultFunc(t *testing.T) {
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", "- id: \"hello\"\n  translation: \"Hello!\"\n- id: \"bye\"\n  translation: \"Bye!\""},
+		testFile{"de.yaml", "- id: \"hello\"\n  translation: \"Hallo!\""})
+
+	calls := 0
+	opts := TranslateOptions{
+		OnMissing: MissingError,
+		DefaultFunc: func() string {
+			calls++
+			return "Computed"
+		},
+	}
+
+	for i, test := range []struct {
+		lang     string
+		id       string
+		expected string
+		calls    int
+	}{
+		{"de", "hello", "Hallo!", 0},
+		{"de", "bye", "Bye!", 0},
+		{"de", "missing", "Computed", 1},
+		{"en", "missing", "Computed", 2},
+	} {
+		translated, err := translator.Translate(test.lang, test.id, opts)
+		require.NoError(t, err, fmt.Sprintf("[%d] %s", i, test.id))
+		require.Equal(t, test.expected, translated, fmt.Sprintf("[%d] %s", i, test.id))
+		require.Equal(t, test.calls, calls, fmt.Sprintf("[%d] %s", i, test.id))
+	}
+}
+
+func TestI18nTranslateKind(t *testing.T) {
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "title"
+  translation: "Latest posts"
+- id: "home.title"
+  translation: "Welcome to {{ .Site }}"
+- id: "section.title"
+  translation: "Posts in this section"
+`},
+		testFile{"de.yaml", `- id: "title"
+  translation: "Neueste Beiträge"
+`},
+		testFile{"fr.yaml", `- id: "other"
+  translation: "Autre"
+`})
+
+	data := map[string]interface{}{"Site": "Hugo"}
+
+	for i, test := range []struct {
+		lang, kind, id, expected string
+	}{
+		{"en", "home", "title", "Welcome to Hugo"},
+		{"en", "section", "title", "Posts in this section"},
+		{"en", "taxonomy", "title", "Latest posts"},
+		{"en", "", "title", "Latest posts"},
+		// The bare id in the language is preferred over the qualified
+		// one in the default content language.
+		{"de", "home", "title", "Neueste Beiträge"},
+		{"fr", "home", "title", "Welcome to Hugo"},
+		{"fr", "page", "title", "Latest posts"},
+		{"en", "home", "missing", ""},
+	} {
+		translated, err := translator.Translate(test.lang, test.id, TranslateOptions{Data: data, Kind: test.kind})
+		require.NoError(t, err, fmt.Sprintf("[%d] %s", i, test.kind))
+		require.Equal(t, test.expected, translated, fmt.Sprintf("[%d] %s", i, test.kind))
+	}
+}
+
+func TestI18nFallbackLanguages(t *testing.T) {
+	v := newTestConfig()
+	v.Set("translationFallbacks", map[string]interface{}{
+		"it": []string{"nb"},
+		"nb": []string{"da", "it"},
+		"da": []string{"sv"},
+		"sv": []string{"de"},
+	})
+
+	files := []testFile{
+		{"en.yaml", "- id: \"hello\"\n  translation: \"Hello\"\n- id: \"bye\"\n  translation: \"Bye\"\n- id: \"thanks\"\n  translation: \"Thanks\""},
+		{"it.yaml", "- id: \"other\"\n  translation: \"Altro\""},
+		{"nb.yaml", "- id: \"hello\"\n  translation: \"Hei\""},
+		{"sv.yaml", "- id: \"bye\"\n  translation: \"Hej då\""},
+		{"de.yaml", "- id: \"thanks\"\n  translation: \"Danke\""},
+	}
+
+	translator := newTranslatorFromFiles(t, v, files...)
+	require.Equal(t, []string{"nb", "da", "sv", "de", "en"}, translator.fallbackLanguages("it"))
+	require.Equal(t, []string{"en"}, translator.fallbackLanguages("fr"))
+	require.Empty(t, translator.fallbackLanguages("en"))
+
+	for i, test := range []struct {
+		maxDepth       int
+		hello, bye, ok string
+	}{
+		{0, "Hei", "Hej då", "Danke"},
+		{5, "Hei", "Hej då", "Danke"},
+		// Truncated before the default content language.
+		{3, "Hei", "Hej då", ""},
+		{1, "Hei", "", ""},
+	} {
+		v.Set("maxFallbackDepth", test.maxDepth)
+
+		f := translator.Func("it")
+		require.Equal(t, test.hello, f("hello"), fmt.Sprintf("[%d]", i))
+		require.Equal(t, test.bye, f("bye"), fmt.Sprintf("[%d]", i))
+		require.Equal(t, test.ok, f("thanks"), fmt.Sprintf("[%d]", i))
+	}
+
+	v.Set("maxFallbackDepth", 2)
+	require.Equal(t, []string{"nb", "da"}, translator.fallbackLanguages("it"))
+}
+
+func TestI18nTranslateCounted(t *testing.T) {
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "wordCount"
+  translation: "{{ .Name }} wrote {{ .WordCount }} words in {{ .Title }}"
+- id: "tags"
+  translation: "{{ range $i, $tag := .Tags }}{{ if $i }}, {{ end }}{{ $tag }}{{ end }}"
+- id: "plain"
+  translation: "No values"
+- id: "icu"
+  format: icu
+  translation: "{count, plural, one{# post by {name}} other{# posts by {name}}}"
+`})
+
+	data := map[string]interface{}{
+		"Name":      "Steve",
+		"WordCount": 50,
+		"Title":     "Hugo",
+		"Tags":      []string{"a", "b"},
+	}
+
+	for i, test := range []struct {
+		id            string
+		args          interface{}
+		expected      string
+		substitutions int
+	}{
+		{"wordCount", data, "Steve wrote 50 words in Hugo", 3},
+		{"tags", data, "a, b", 2},
+		{"plain", data, "No values", 0},
+		{"icu", map[string]interface{}{"count": 2, "name": "Steve"}, "2 posts by Steve", 2},
+		{"missing", data, "", 0},
+	} {
+		translated, substitutions := translator.TranslateCounted("en", test.id, test.args)
+		require.Equal(t, test.expected, translated, fmt.Sprintf("[%d] %s", i, test.id))
+		require.Equal(t, test.substitutions, substitutions, fmt.Sprintf("[%d] %s", i, test.id))
+	}
+
+	// The counting does not leak into uncounted translations.
+	require.Equal(t, "Steve wrote 50 words in Hugo", translator.Func("en")("wordCount", data))
+}
+
+func TestI18nAddLanguage(t *testing.T) {
+	v := viper.New()
+	v.SetDefault("defaultContentLanguage", "en")
+
+	i18nBundle := bundle.New()
+	require.NoError(t, i18nBundle.ParseTranslationFileBytes("en.yaml",
+		[]byte("- id: \"hello\"\n  translation: \"Hello, World!\"")))
+	require.NoError(t, i18nBundle.ParseTranslationFileBytes("es.yaml",
+		[]byte("- id: \"hello\"\n  translation: \"¡Hola, Mundo!\"")))
+
+	translator := NewTranslator(i18nBundle, v, logger)
+
+	// Not loaded yet, so the default language is used.
+	require.Equal(t, "Hello, World!", translator.Func("fr")("hello"))
+
+	require.NoError(t, translator.AddLanguage("fr", []byte("- id: \"hello\"\n  translation: \"Bonjour, le monde !\"")))
+
+	require.Equal(t, "Bonjour, le monde !", translator.Func("fr")("hello"))
+	require.Equal(t, "¡Hola, Mundo!", translator.Func("es")("hello"))
+
+	require.Error(t, translator.AddLanguage("fr", []byte("- id: \"broken\"\n  translation: \"{{ .Broken\"")))
+}
+
+func TestI18nMissingLanguageWarnedOnce(t *testing.T) {
+	var buf bytes.Buffer
+	warnLogger := jww.NewNotepad(jww.LevelWarn, jww.LevelError, &buf, ioutil.Discard, "", 0)
+
+	v := viper.New()
+	v.SetDefault("defaultContentLanguage", "en")
+
+	i18nBundle := bundle.New()
+	require.NoError(t, i18nBundle.ParseTranslationFileBytes("en.yaml",
+		[]byte("- id: \"hello\"\n  translation: \"Hello, World!\"")))
+
+	translator := NewTranslator(i18nBundle, v, warnLogger)
+
+	require.False(t, translator.hasLanguage("it"))
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, "Hello, World!", translator.Func("it")("hello"))
+		translated, err := translator.Translate("it", "hello", TranslateOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "Hello, World!", translated)
+	}
+
+	require.Equal(t, 1, strings.Count(buf.String(), `No translations found for language "it"`), buf.String())
+}
+
+func TestI18nFlaggedVariants(t *testing.T) {
+	en := `- id: "welcome"
+  translation: "Welcome!"
+- id: "welcome"
+  flags: [betaCopy]
+  translation: "Welcome to the beta!"
+- id: "welcome"
+  flags: [betaCopy, loud]
+  translation: "WELCOME TO THE BETA!"
+- id: "signup"
+  flags: [betaCopy]
+  translation: "Join the beta"
+`
+
+	for i, test := range []struct {
+		flags           []string
+		welcome, signup string
+	}{
+		{nil, "Welcome!", ""},
+		{[]string{"other"}, "Welcome!", ""},
+		{[]string{"betaCopy"}, "Welcome to the beta!", "Join the beta"},
+		{[]string{"loud"}, "Welcome!", ""},
+		{[]string{"loud", "betaCopy"}, "WELCOME TO THE BETA!", "Join the beta"},
+	} {
+		v := viper.New()
+		v.SetDefault("defaultContentLanguage", "en")
+		v.Set("translationFlags", test.flags)
+
+		f := newTranslatorFromFiles(t, v, testFile{"en.yaml", en}).Func("en")
+
+		require.Equal(t, test.welcome, f("welcome"), fmt.Sprintf("[%d] welcome", i))
+		require.Equal(t, test.signup, f("signup"), fmt.Sprintf("[%d] signup", i))
+	}
+}
+
+func TestI18nEnabledLanguages(t *testing.T) {
+	v := newTestConfig()
+	v.Set("enabledLanguages", []string{"es"})
+
+	translator := newTranslatorFromFiles(t, v,
+		testFile{"en.yaml", "- id: \"hello\"\n  translation: \"Hello, World!\""},
+		testFile{"es.yaml", "- id: \"hello\"\n  translation: \"¡Hola, Mundo!\""},
+		testFile{"fr.yaml", "- id: \"hello\"\n  translation: \"Bonjour, le monde !\""},
+	)
+
+	require.Equal(t, "¡Hola, Mundo!", translator.Func("es")("hello"))
+	require.Equal(t, "Hello, World!", translator.Func("en")("hello"))
+
+	// Loaded, but not enabled.
+	require.False(t, translator.hasLanguage("fr"))
+	require.Equal(t, "Hello, World!", translator.Func("fr")("hello"))
+	translated, err := translator.Translate("fr", "hello", TranslateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "Hello, World!", translated)
+}
+
+func TestI18nFuncHTMLWrapFallback(t *testing.T) {
+	files := []testFile{
+		{"en.yaml", "- id: \"hello\"\n  translation: \"Hello & welcome!\"\n- id: \"bye\"\n  translation: \"Goodbye!\""},
+		{"de.yaml", "- id: \"bye\"\n  translation: \"Auf Wiedersehen!\""},
+	}
+
+	v := newTestConfig()
+	v.Set("wrapFallbackTranslations", true)
+	f := newTranslatorFromFiles(t, v, files...).FuncHTML("de")
+
+	require.Equal(t, template.HTML(`<span lang="en">Hello &amp; welcome!</span>`), f("hello"))
+	require.Equal(t, template.HTML("Auf Wiedersehen!"), f("bye"))
+	require.Equal(t, template.HTML(""), f("missing"))
+
+	// Nothing to wrap in the default language.
+	f = newTranslatorFromFiles(t, v, files...).FuncHTML("en")
+	require.Equal(t, template.HTML("Hello &amp; welcome!"), f("hello"))
+
+	f = newTranslatorFromFiles(t, newTestConfig(), files...).FuncHTML("de")
+	require.Equal(t, template.HTML("Hello &amp; welcome!"), f("hello"))
+}
@@ -0,0 +1,370 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cast"
+)
+
+// icuMessage is a translation in the ICU MessageFormat syntax, e.g.
+//
+//	{gender, select, male{He has {count, plural, one{# message} other{# messages}}} other{...}}
+//
+// Simple, plural and select arguments are supported, and may be nested.
+type icuMessage struct {
+	src   string
+	nodes []*icuNode
+}
+
+// icuNode is literal text, a "#" or an argument in an ICU message.
+type icuNode struct {
+	text string
+
+	// The count of the innermost plural argument, inserted for "#".
+	hash bool
+
+	// The argument name and, for plural and select arguments, the type and
+	// the messages per selector.
+	arg     string
+	kind    string
+	offset  float64
+	options map[string][]*icuNode
+}
+
+func parseICUMessage(src string) (*icuMessage, error) {
+	p := &icuParser{src: src}
+	nodes, err := p.parseMessage(0, false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(src) {
+		return nil, p.errorf("unexpected %q", src[p.pos])
+	}
+	return &icuMessage{src: src, nodes: nodes}, nil
+}
+
+type icuParser struct {
+	src string
+	pos int
+}
+
+func (p *icuParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Invalid ICU message at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseMessage parses text and arguments up to the end of src, or up to
+// the closing brace of a nested message. A "#" is only special inside a
+// plural argument.
+func (p *icuParser) parseMessage(depth int, inPlural bool) ([]*icuNode, error) {
+	var (
+		nodes []*icuNode
+		text  bytes.Buffer
+	)
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &icuNode{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\'':
+			p.parseQuoted(&text)
+		case c == '#' && inPlural:
+			flush()
+			nodes = append(nodes, &icuNode{hash: true})
+			p.pos++
+		case c == '{':
+			flush()
+			node, err := p.parseArgument(depth, inPlural)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		case c == '}':
+			if depth == 0 {
+				return nil, p.errorf("unexpected %q", c)
+			}
+			flush()
+			return nodes, nil
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	if depth > 0 {
+		return nil, p.errorf("unclosed message")
+	}
+	flush()
+	return nodes, nil
+}
+
+// parseQuoted handles an apostrophe: two of them are a literal apostrophe, and an
+// apostrophe before a brace or "#" quotes everything up to the next one.
+func (p *icuParser) parseQuoted(text *bytes.Buffer) {
+	p.pos++
+	if p.pos >= len(p.src) {
+		text.WriteByte('\'')
+		return
+	}
+	switch p.src[p.pos] {
+	case '\'':
+		text.WriteByte('\'')
+		p.pos++
+	case '{', '}', '#':
+		end := strings.IndexByte(p.src[p.pos:], '\'')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		text.WriteString(p.src[p.pos : p.pos+end])
+		p.pos += end + 1
+	default:
+		text.WriteByte('\'')
+	}
+}
+
+func (p *icuParser) parseArgument(depth int, inPlural bool) (*icuNode, error) {
+	p.pos++ // {
+
+	node := &icuNode{arg: p.parseWord()}
+	if node.arg == "" {
+		return nil, p.errorf("missing argument name")
+	}
+
+	if p.consume('}') {
+		return node, nil
+	}
+	if !p.consume(',') {
+		return nil, p.errorf("expected ',' or '}' after argument %q", node.arg)
+	}
+
+	node.kind = p.parseWord()
+	switch node.kind {
+	case "plural":
+		inPlural = true
+	case "select":
+	default:
+		return nil, p.errorf("unsupported type %q for argument %q", node.kind, node.arg)
+	}
+	if !p.consume(',') {
+		return nil, p.errorf("expected ',' after type of argument %q", node.arg)
+	}
+
+	node.options = make(map[string][]*icuNode)
+	for {
+		p.skipSpace()
+		if p.consume('}') {
+			break
+		}
+
+		selector := p.parseWord()
+		if selector == "" {
+			return nil, p.errorf("missing selector in argument %q", node.arg)
+		}
+
+		if node.kind == "plural" && strings.HasPrefix(selector, "offset:") {
+			offset, err := cast.ToFloat64E(strings.TrimPrefix(selector, "offset:"))
+			if err != nil {
+				return nil, p.errorf("invalid offset in argument %q", node.arg)
+			}
+			node.offset = offset
+			continue
+		}
+
+		p.skipSpace()
+		if !p.consume('{') {
+			return nil, p.errorf("expected '{' after selector %q", selector)
+		}
+		msg, err := p.parseMessage(depth+1, inPlural)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++ // }
+		node.options[selector] = msg
+	}
+
+	if _, found := node.options["other"]; !found {
+		return nil, p.errorf("argument %q has no \"other\" selector", node.arg)
+	}
+
+	return node, nil
+}
+
+// parseWord skips leading white space and returns the name, type or
+// selector that follows.
+func (p *icuParser) parseWord() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := rune(p.src[p.pos])
+		if c == '{' || c == '}' || c == ',' || unicode.IsSpace(c) {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *icuParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *icuParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// formatICU formats msg, the ICU message of translationID, in lang. If
+// localizeQuotes is set, the quotes of the message are localized, but not
+// those of the values inserted into it.
+func (t *Translator) formatICU(msg *icuMessage, lang, translationID string, opts resolveOptions, args []interface{}) string {
+	insert := t.insertValue(lang, translationID, false, opts.substitutions)
+	if !t.cfg.GetBool("localizeQuotes") {
+		return msg.format(lang, insert, args...)
+	}
+
+	var values []string
+	placeholders := func(v interface{}) interface{} {
+		values = append(values, cast.ToString(insert(v)))
+		return "\x00arg" + strconv.Itoa(len(values)-1) + "\x00"
+	}
+	s := t.localizeQuotes(lang, msg.format(lang, placeholders, args...), insertedOpaqueLen)
+	for i, v := range values {
+		s = strings.Replace(s, "\x00arg"+strconv.Itoa(i)+"\x00", v, 1)
+	}
+	return s
+}
+
+// format renders m in lang. The arguments are looked up in the translate
+// func args: the count, if given, is the "count" argument, and the others
+// are taken from the map or struct passed as data. Every argument and "#"
+// is inserted through insert.
+func (m *icuMessage) format(lang string, insert func(interface{}) interface{}, args ...interface{}) string {
+	var buf bytes.Buffer
+	formatICUNodes(&buf, lang, m.nodes, icuArgs(args), nil, insert)
+	return buf.String()
+}
+
+func formatICUNodes(buf *bytes.Buffer, lang string, nodes []*icuNode, args icuArgs, count interface{}, insert func(interface{}) interface{}) {
+	for _, node := range nodes {
+		switch {
+		case node.hash:
+			buf.WriteString(cast.ToString(insert(count)))
+		case node.arg == "":
+			buf.WriteString(node.text)
+		case node.kind == "":
+			if v, found := args.get(node.arg); found {
+				buf.WriteString(cast.ToString(insert(v)))
+			}
+		case node.kind == "select":
+			v, _ := args.get(node.arg)
+			msg, found := node.options[cast.ToString(v)]
+			if !found {
+				msg = node.options["other"]
+			}
+			formatICUNodes(buf, lang, msg, args, count, insert)
+		case node.kind == "plural":
+			v, _ := args.get(node.arg)
+			n := cast.ToFloat64(v) - node.offset
+			msg, found := node.options[fmt.Sprintf("=%v", cast.ToFloat64(v))]
+			if !found {
+				msg, found = node.options[string(pluralCategory(lang, icuCount(n)))]
+			}
+			if !found {
+				msg = node.options["other"]
+			}
+			formatICUNodes(buf, lang, msg, args, icuCount(n), insert)
+		}
+	}
+}
+
+// icuCount returns n as an int if it is whole, so it is printed and
+// pluralized without decimals.
+func icuCount(n float64) interface{} {
+	if n == float64(int64(n)) {
+		return int64(n)
+	}
+	return n
+}
+
+// icuArgs are the args passed to a translate func, i.e. the data,
+// optionally preceded by a count.
+type icuArgs []interface{}
+
+func (a icuArgs) get(name string) (interface{}, bool) {
+	if len(a) == 0 {
+		return nil, false
+	}
+	if name == "count" && (len(a) > 1 || isNumber(a[0])) {
+		return a[0], true
+	}
+	return fieldValue(a[len(a)-1], name)
+}
+
+func isNumber(v interface{}) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldValue returns the entry or field called name in data, a map or a
+// pointer to a struct. The first letter of a field name may be given in
+// lower case.
+func fieldValue(data interface{}, name string) (interface{}, bool) {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		if e := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())); e.IsValid() {
+			return e.Interface(), true
+		}
+	case reflect.Struct:
+		for _, n := range []string{name, strings.ToUpper(name[:1]) + name[1:]} {
+			if f := v.FieldByName(n); f.IsValid() && f.CanInterface() {
+				return f.Interface(), true
+			}
+		}
+	}
+	return nil, false
+}
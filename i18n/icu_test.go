@@ -0,0 +1,85 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var icuTestFiles = []testFile{
+	{"en.yaml", `- id: "messages"
+  format: icu
+  translation: "{gender, select, male{He has {count, plural, one{# message} other{# messages}}} female{She has {count, plural, one{# message} other{# messages}}} other{They have {count, plural, one{# message} other{# messages}}}}"
+- id: "guests"
+  format: icu
+  translation: "{count, plural, =0{Nobody came} one{{gender, select, female{She} male{He} other{They}} came alone} other{# guests came, it''s a '{party}'}}"
+`},
+	{"pl.yaml", `- id: "messages"
+  format: icu
+  translation: "{gender, select, male{Ma {count, plural, one{# wiadomość} few{# wiadomości} many{# wiadomości} other{# wiadomości}}} female{Ma {count, plural, one{# wiadomość} few{# wiadomości} many{# wiadomości} other{# wiadomości}}} other{Mają {count, plural, one{# wiadomość} few{# wiadomości} many{# wiadomości} other{# wiadomości}}}}"
+`},
+}
+
+func TestICUNested(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), icuTestFiles...)
+
+	for i, test := range []struct {
+		lang     string
+		id       string
+		args     []interface{}
+		expected string
+	}{
+		{"en", "messages", []interface{}{map[string]interface{}{"gender": "female", "count": 1}}, "She has 1 message"},
+		{"en", "messages", []interface{}{map[string]interface{}{"gender": "male", "count": 3}}, "He has 3 messages"},
+		{"en", "messages", []interface{}{map[string]interface{}{"count": 2}}, "They have 2 messages"},
+		{"en", "messages", []interface{}{5, struct{ Gender string }{"male"}}, "He has 5 messages"},
+		{"en", "guests", []interface{}{map[string]interface{}{"count": 0}}, "Nobody came"},
+		{"en", "guests", []interface{}{map[string]interface{}{"count": 1, "gender": "male"}}, "He came alone"},
+		{"en", "guests", []interface{}{7}, "7 guests came, it's a {party}"},
+		{"pl", "messages", []interface{}{map[string]interface{}{"gender": "female", "count": 1}}, "Ma 1 wiadomość"},
+		{"pl", "messages", []interface{}{map[string]interface{}{"gender": "male", "count": 3}}, "Ma 3 wiadomości"},
+		{"pl", "messages", []interface{}{map[string]interface{}{"count": 5}}, "Mają 5 wiadomości"},
+	} {
+		require.Equal(t, test.expected, tr.Func(test.lang)(test.id, test.args...), fmt.Sprintf("[%d] %s", i, test.id))
+	}
+
+	src, found := tr.Raw("pl", "messages")
+	require.True(t, found)
+	require.Contains(t, src, "{gender, select,")
+}
+
+func TestICUOverride(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(),
+		icuTestFiles[0],
+		testFile{"en.yaml", "- id: \"messages\"\n  translation: \"Messages\""})
+
+	require.Equal(t, "Messages", tr.Func("en")("messages"))
+	require.Equal(t, "Nobody came", tr.Func("en")("guests", 0))
+}
+
+func TestICUParseErrors(t *testing.T) {
+	for i, src := range []string{
+		"{count, plural, one{# message}}",
+		"{count, plural, other{# messages}",
+		"{count, number}",
+		"Hello {}",
+		"Hello }",
+	} {
+		_, err := parseICUMessage(src)
+		require.Error(t, err, fmt.Sprintf("[%d] %s", i, src))
+	}
+}
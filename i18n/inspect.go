@@ -0,0 +1,178 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+	"unicode/utf8"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+)
+
+// LongestForm renders translationID with args in every loaded language and
+// returns the language with the longest result, measured in runes, and that
+// result. Languages without the translation are skipped; ties go to the
+// language first in alphabetical order.
+func (t *Translator) LongestForm(translationID string, args interface{}) (lang string, value string) {
+	longest := -1
+	for _, l := range t.languages() {
+		translated, found := t.lookup(l, translationID, resolveOptions{}, args)
+		if !found {
+			continue
+		}
+		if n := utf8.RuneCountInString(translated); n > longest {
+			lang, value, longest = l, translated, n
+		}
+	}
+	return
+}
+
+// languages returns the loaded languages, sorted, including those with
+// only ICU or bucket messages.
+func (t *Translator) languages() []string {
+	seen := make(map[string]bool)
+	for _, lang := range t.bundle.LanguageTags() {
+		seen[lang] = true
+	}
+	t.mu.RLock()
+	for lang, m := range t.icuMessages {
+		if len(m) > 0 {
+			seen[lang] = true
+		}
+	}
+	for lang, m := range t.bucketMessages {
+		if len(m) > 0 {
+			seen[lang] = true
+		}
+	}
+	t.mu.RUnlock()
+
+	langs := make([]string, 0, len(seen))
+	for lang := range seen {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Variables returns the names of the fields of the data that translationID
+// uses in any language, including its variants, sorted, e.g. "Count" and
+// "Page.Title". Fields of the values ranged over are not included, unless
+// used through $. For ICU messages, these are the argument names.
+func (t *Translator) Variables(translationID string) []string {
+	vars := make(map[string]bool)
+	var templates []string
+
+	bundles := []*bundle.Bundle{t.bundle}
+	t.mu.RLock()
+	for _, fb := range t.flagged {
+		bundles = append(bundles, fb.bundle)
+	}
+	for _, messages := range t.icuMessages {
+		if msg, found := messages[translationID]; found {
+			icuVariables(msg.nodes, vars)
+		}
+	}
+	for _, messages := range t.bucketMessages {
+		if msg, found := messages[translationID]; found {
+			templates = append(templates, msg.templates()...)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, b := range bundles {
+		for _, translations := range b.Translations() {
+			if tr, found := translations[translationID]; found {
+				templates = append(templates, translationTemplates(tr)...)
+			}
+		}
+	}
+
+	for _, src := range templates {
+		tmpl, err := t.parseWithAllFuncs(src)
+		if err != nil {
+			continue
+		}
+		templateVariables(tmpl.Tree.Root, vars, true)
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateVariables adds the fields of the data used in node to vars. Inside
+// range and with, dot is another value, so its fields are only added if
+// isData is set, while those of $ always are.
+func templateVariables(node parse.Node, vars map[string]bool, isData bool) {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		if isData {
+			vars[strings.Join(n.Ident, ".")] = true
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			vars[strings.Join(n.Ident[1:], ".")] = true
+		}
+	case *parse.ChainNode:
+		templateVariables(n.Node, vars, isData)
+	case *parse.ListNode:
+		for _, c := range n.Nodes {
+			templateVariables(c, vars, isData)
+		}
+	case *parse.ActionNode:
+		templateVariables(n.Pipe, vars, isData)
+	case *parse.IfNode:
+		templateVariables(n.Pipe, vars, isData)
+		templateVariables(n.List, vars, isData)
+		templateVariables(n.ElseList, vars, isData)
+	case *parse.RangeNode:
+		templateVariables(n.Pipe, vars, isData)
+		templateVariables(n.List, vars, false)
+		templateVariables(n.ElseList, vars, isData)
+	case *parse.WithNode:
+		templateVariables(n.Pipe, vars, isData)
+		templateVariables(n.List, vars, false)
+		templateVariables(n.ElseList, vars, isData)
+	case *parse.PipeNode:
+		for _, c := range n.Cmds {
+			templateVariables(c, vars, isData)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			templateVariables(arg, vars, isData)
+		}
+	}
+}
+
+func icuVariables(nodes []*icuNode, vars map[string]bool) {
+	for _, node := range nodes {
+		if node.arg != "" {
+			vars[node.arg] = true
+		}
+		for _, msg := range node.options {
+			icuVariables(msg, vars)
+		}
+	}
+}
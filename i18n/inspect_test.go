@@ -0,0 +1,65 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongestForm(t *testing.T) {
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", "- id: \"save\"\n  translation: \"Save {{.Count}} files\""},
+		testFile{"es.yaml", "- id: \"save\"\n  translation: \"Guardar {{.Count}} archivos\""},
+		testFile{"de.yaml", "- id: \"other\"\n  translation: \"Etwas ganz anderes, das länger ist\""},
+	)
+
+	lang, value := translator.LongestForm("save", map[string]interface{}{"Count": 3})
+	require.Equal(t, "es", lang)
+	require.Equal(t, "Guardar 3 archivos", value)
+
+	lang, value = translator.LongestForm("missing", nil)
+	require.Equal(t, "", lang)
+	require.Equal(t, "", value)
+}
+
+func TestVariables(t *testing.T) {
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "comments"
+  translation:
+    one: "One comment on {{ .Page.Title }}"
+    other: "{{ .Count }} comments on {{ .Page.Title }}"
+- id: "tags"
+  translation: "{{ range .Tags }}{{ .Name }} ({{ $.Site }}){{ else }}{{ .Empty }}{{ end }}"
+`},
+		testFile{"de.yaml", `- id: "comments"
+  translation:
+    one: "Ein Kommentar von {{ .Page.Author }} zu {{ .Page.Title }}"
+    other: "{{ .Count }} Kommentare von {{ .Page.Author }} zu {{ .Page.Title | printf \"%q\" }}"
+- id: "comments"
+  flags: [beta]
+  translation: "{{ .Count }} Kommentare seit {{ .Since }}"
+`},
+		testFile{"fr.yaml", `- id: "comments"
+  format: icu
+  translation: "{count, plural, one{# commentaire de {author}} other{# commentaires}}"
+`},
+		testFile{"nb.json", `[{"id": "comments", "format": "buckets", "translation": {"0": "Ingen kommentarer", "1+": "{{ .Count }} kommentarer på {{ .Post }}"}}]`},
+	)
+
+	require.Equal(t, []string{"Count", "Page.Author", "Page.Title", "Post", "Since", "author", "count"}, translator.Variables("comments"))
+	require.Equal(t, []string{"Empty", "Site", "Tags"}, translator.Variables("tags"))
+	require.Empty(t, translator.Variables("missing"))
+}
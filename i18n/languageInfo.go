@@ -0,0 +1,103 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// LanguageInfo describes a language.
+type LanguageInfo struct {
+	Lang string
+
+	// The name of the language in the language itself, e.g. "Deutsch".
+	DisplayName string
+
+	// Whether the language is written right to left.
+	RTL bool
+}
+
+var (
+	// The CLDR native names of the languages, by lower case tag.
+	nativeLanguageNames = map[string]string{
+		"ar":      "العربية",
+		"da":      "dansk",
+		"de":      "Deutsch",
+		"en":      "English",
+		"en-gb":   "British English",
+		"en-us":   "American English",
+		"es":      "español",
+		"fa":      "فارسی",
+		"fi":      "suomi",
+		"fr":      "français",
+		"he":      "עברית",
+		"it":      "italiano",
+		"ja":      "日本語",
+		"ko":      "한국어",
+		"nb":      "norsk bokmål",
+		"nl":      "Nederlands",
+		"nn":      "nynorsk",
+		"pl":      "polski",
+		"pt":      "português",
+		"pt-br":   "português (Brasil)",
+		"ru":      "русский",
+		"sv":      "svenska",
+		"tr":      "Türkçe",
+		"uk":      "українська",
+		"zh":      "中文",
+		"zh-hans": "简体中文",
+		"zh-hant": "繁體中文",
+	}
+
+	rtlLanguages = map[string]bool{"ar": true, "fa": true, "he": true, "ur": true}
+)
+
+// SetDisplayName sets the display name of lang returned by LanguageInfo,
+// e.g. to use the label a site prefers over the one in CLDR.
+func (t *Translator) SetDisplayName(lang, name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.displayNames == nil {
+		t.displayNames = make(map[string]string)
+	}
+	t.displayNames[lang] = name
+}
+
+// LanguageInfo returns information about lang. The display name is the one
+// set with SetDisplayName, else the native name of lang in CLDR, else lang.
+func (t *Translator) LanguageInfo(lang string) LanguageInfo {
+	tag := language.Make(lang)
+	base, _ := tag.Base()
+
+	info := LanguageInfo{Lang: lang, RTL: rtlLanguages[base.String()]}
+
+	t.mu.RLock()
+	name, found := t.displayNames[lang]
+	t.mu.RUnlock()
+
+	switch {
+	case found:
+		info.DisplayName = name
+	case nativeLanguageNames[strings.ToLower(tag.String())] != "":
+		info.DisplayName = nativeLanguageNames[strings.ToLower(tag.String())]
+	case nativeLanguageNames[base.String()] != "":
+		info.DisplayName = nativeLanguageNames[base.String()]
+	default:
+		info.DisplayName = lang
+	}
+
+	return info
+}
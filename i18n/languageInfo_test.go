@@ -0,0 +1,35 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageInfo(t *testing.T) {
+	tr := newTestTranslator()
+
+	require.Equal(t, LanguageInfo{Lang: "de", DisplayName: "Deutsch"}, tr.LanguageInfo("de"))
+	require.Equal(t, LanguageInfo{Lang: "de-AT", DisplayName: "Deutsch"}, tr.LanguageInfo("de-AT"))
+	require.Equal(t, LanguageInfo{Lang: "he", DisplayName: "עברית", RTL: true}, tr.LanguageInfo("he"))
+	require.Equal(t, "简体中文", tr.LanguageInfo("zh-Hans").DisplayName)
+	require.Equal(t, "xx", tr.LanguageInfo("xx").DisplayName)
+
+	tr.SetDisplayName("zh-Hans", "中文（简体）")
+	require.Equal(t, "中文（简体）", tr.LanguageInfo("zh-Hans").DisplayName)
+	require.Equal(t, "中文", tr.LanguageInfo("zh").DisplayName)
+	require.Equal(t, "Deutsch", tr.LanguageInfo("de").DisplayName)
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+// ResolveFunc resolves translationID in lang with the translate func args.
+// It returns the translation, the language it was taken from, or an empty
+// string if it is missing, and the error for a missing translation if
+// missingTranslationBehavior is "error", or for a removed deprecated id.
+type ResolveFunc func(lang, translationID string, args ...interface{}) (translated, translatedLang string, err error)
+
+// Middleware wraps resolving translations, e.g. to record metrics, trace or
+// transform the translations. It returns a ResolveFunc that usually calls
+// next, which resolves the translation, possibly through other middleware.
+type Middleware func(next ResolveFunc) ResolveFunc
+
+// AddMiddleware makes m wrap the resolving of every translation by t, by all
+// of its translate funcs and Translate. The middleware added first is the
+// outermost.
+func (t *Translator) AddMiddleware(m Middleware) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Copied, as resolve calls the middleware without holding mu.
+	t.middleware = append(append([]Middleware(nil), t.middleware...), m)
+}
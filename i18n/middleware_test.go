@@ -0,0 +1,58 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello, {{ .Name }}!"
+`},
+		testFile{"de.yaml", `- id: "bye"
+  translation: "Tschüss"
+`},
+	)
+
+	var calls []string
+	tr.AddMiddleware(func(next ResolveFunc) ResolveFunc {
+		return func(lang, translationID string, args ...interface{}) (string, string, error) {
+			calls = append(calls, lang+":"+translationID)
+			return next(lang, translationID, args...)
+		}
+	})
+	tr.AddMiddleware(func(next ResolveFunc) ResolveFunc {
+		return func(lang, translationID string, args ...interface{}) (string, string, error) {
+			translated, translatedLang, err := next(lang, translationID, args...)
+			return strings.ToUpper(translated), translatedLang, err
+		}
+	})
+
+	data := map[string]interface{}{"Name": "Steve"}
+	require.Equal(t, "HELLO, STEVE!", tr.Func("en")("hello", data))
+	require.Equal(t, "HELLO, STEVE!", tr.Func("de")("hello", data))
+	require.Equal(t, "TSCHÜSS", tr.Func("de")("bye"))
+	require.Equal(t, "HELLO, &lt;B&gt;!", string(tr.FuncHTML("en")("hello", map[string]interface{}{"Name": "<b>"})))
+
+	translated, err := tr.Translate("de", "hello", TranslateOptions{Data: data})
+	require.NoError(t, err)
+	require.Equal(t, "HELLO, STEVE!", translated)
+
+	require.Equal(t, []string{"en:hello", "de:hello", "de:bye", "en:hello", "de:hello"}, calls)
+}
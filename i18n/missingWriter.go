@@ -0,0 +1,93 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cast"
+)
+
+// missingWriter appends translations missing in the default content language
+// to a YAML translation file, with an empty translation, so translators can
+// pick them up. Every id is added only once.
+type missingWriter struct {
+	fs       afero.Fs
+	filename string
+
+	// Translations are looked up concurrently, so writes must be serialized.
+	mu      sync.Mutex
+	written map[string]bool
+}
+
+func newMissingWriter(fs afero.Fs, filename string) (*missingWriter, error) {
+	w := &missingWriter{fs: fs, filename: filename, written: make(map[string]bool)}
+
+	// Do not add the ids already added in an earlier build again.
+	exists, err := afero.Exists(fs, filename)
+	if err != nil || !exists {
+		return w, err
+	}
+
+	content, err := afero.ReadFile(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+	entries, _, err := decodeTranslationFile(filename, content)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		w.written[cast.ToString(entry["id"])] = true
+	}
+
+	return w, nil
+}
+
+func (w *missingWriter) add(translationID string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.written[translationID] {
+		return nil
+	}
+
+	f, err := w.fs.OpenFile(w.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "- id: %q\n  translation: \"\"\n", translationID); err != nil {
+		return err
+	}
+
+	w.written[translationID] = true
+
+	return nil
+}
+
+// setMissingTranslationsFile makes t add translations missing in the default
+// content language to the given file.
+func (t *Translator) setMissingTranslationsFile(fs afero.Fs, filename string) error {
+	w, err := newMissingWriter(fs, filename)
+	if err != nil {
+		return fmt.Errorf("Failed to read missing translations file %q: %s", filename, err)
+	}
+	t.missingWriter = w
+	return nil
+}
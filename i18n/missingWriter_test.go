@@ -0,0 +1,59 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingTranslationsFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	filename := "/i18n/missing.en.yaml"
+
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", "- id: \"hello\"\n  translation: \"Hello, World!\""},
+		testFile{"es.yaml", "- id: \"goodbye\"\n  translation: \"¡Adiós, Mundo!\""},
+	)
+	require.NoError(t, translator.setMissingTranslationsFile(fs, filename))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			translator.Func("es")("missing")
+			// Only missing in es, not in the default language.
+			translator.Func("es")("hello")
+		}()
+	}
+	wg.Wait()
+
+	content, err := afero.ReadFile(fs, filename)
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(content), `id: "missing"`), string(content))
+	require.NotContains(t, string(content), "hello")
+
+	// A new translator, e.g. after a rebuild, does not add it again.
+	require.NoError(t, translator.setMissingTranslationsFile(fs, filename))
+	translator.Func("en")("missing")
+
+	content, err = afero.ReadFile(fs, filename)
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(content), `id: "missing"`), string(content))
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strconv"
+
+	"github.com/spf13/cast"
+	"golang.org/x/text/language"
+)
+
+// ordinalFuncs format ordinal numbers, by language.
+var ordinalFuncs = map[string]func(n int) string{
+	"en": func(n int) string {
+		suffix := "th"
+		switch {
+		case n%100 >= 11 && n%100 <= 13:
+		case n%10 == 1:
+			suffix = "st"
+		case n%10 == 2:
+			suffix = "nd"
+		case n%10 == 3:
+			suffix = "rd"
+		}
+		return strconv.Itoa(n) + suffix
+	},
+	"fr": func(n int) string {
+		if n == 1 {
+			return "1er"
+		}
+		return strconv.Itoa(n) + "e"
+	},
+	"nl": func(n int) string { return strconv.Itoa(n) + "e" },
+	"de": dotOrdinal,
+	"da": dotOrdinal,
+	"fi": dotOrdinal,
+	"nb": dotOrdinal,
+	"es": masculineOrdinal,
+	"it": masculineOrdinal,
+	"pt": masculineOrdinal,
+}
+
+func dotOrdinal(n int) string       { return strconv.Itoa(n) + "." }
+func masculineOrdinal(n int) string { return strconv.Itoa(n) + "º" }
+
+// Ordinal formats n as an ordinal number in lang, e.g. "2nd" in English
+// and "2." in German. In languages not known, n is returned as is. It is
+// available as the ordinal func in translations.
+func (t *Translator) Ordinal(lang string, n interface{}) string {
+	i := cast.ToInt(n)
+	base, _ := language.Make(lang).Base()
+	if f, found := ordinalFuncs[base.String()]; found {
+		return f(i)
+	}
+	return strconv.Itoa(i)
+}
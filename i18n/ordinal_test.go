@@ -0,0 +1,66 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrdinal(t *testing.T) {
+	tr := newTestTranslator()
+
+	for i, test := range []struct {
+		lang     string
+		n        interface{}
+		expected string
+	}{
+		{"en", 1, "1st"},
+		{"en", 2, "2nd"},
+		{"en-US", 3, "3rd"},
+		{"en", 4, "4th"},
+		{"en", 11, "11th"},
+		{"en", 12, "12th"},
+		{"en", 13, "13th"},
+		{"en", 21, "21st"},
+		{"en", 102, "102nd"},
+		{"en", "112", "112th"},
+		{"de", 2, "2."},
+		{"fr", 1, "1er"},
+		{"fr", 2, "2e"},
+		{"es", 3, "3º"},
+		{"ja", 4, "4"},
+	} {
+		require.Equal(t, test.expected, tr.Ordinal(test.lang, test.n), fmt.Sprintf("[%d] %s %v", i, test.lang, test.n))
+	}
+}
+
+func TestOrdinalInTranslation(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", "- id: \"place\"\n  translation: \"{{ ordinal .Place }} place\"\n- id: \"placeCount\"\n  translation:\n    one: \"{{ ordinal .Count }} place\"\n    other: \"{{ .Count | ordinal }} places\""},
+		testFile{"de.yaml", "- id: \"place\"\n  translation: \"{{ ordinal .Place }} Platz\""})
+
+	require.Equal(t, "2nd place", tr.Func("en")("place", map[string]interface{}{"Place": 2}))
+	require.Equal(t, "2. Platz", tr.Func("de")("place", map[string]interface{}{"Place": 2}))
+	require.Equal(t, "1st place", tr.Func("en")("placeCount", 1))
+	require.Equal(t, "3rd places", tr.Func("en")("placeCount", 3))
+
+	src, found := tr.Raw("en", "place")
+	require.True(t, found)
+	require.Equal(t, "{{ ordinal .Place }} place", src)
+
+	require.Error(t, newTestTranslator().addTranslationFile("en.yaml", []byte("- id: \"place\"\n  translation: \"{{ unknownFunc .Place }}\"")))
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/i18n/language"
+)
+
+// PluralInline selects the form for count from forms, a map from CLDR plural
+// category ("one", "other" etc.) to text, using the plural rules of lang.
+// Any "#" in the selected form is replaced with count. The "other" form is
+// used if there is none for the category of count.
+func (t *Translator) PluralInline(lang string, count interface{}, forms map[string]string) string {
+	form, found := forms[string(pluralCategory(lang, count))]
+	if !found {
+		form = forms[string(language.Other)]
+	}
+	return strings.Replace(form, "#", fmt.Sprint(count), -1)
+}
+
+// pluralCategory returns the CLDR plural category of count in lang, or
+// "other" if the plural rules of lang are not known.
+func pluralCategory(lang string, count interface{}) language.Plural {
+	if langs := language.Parse(lang); len(langs) > 0 {
+		if category, err := langs[0].Plural(count); err == nil {
+			return category
+		}
+	}
+	return language.Other
+}
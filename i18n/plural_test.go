@@ -0,0 +1,31 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluralInline(t *testing.T) {
+	translator := newTestTranslator()
+	forms := map[string]string{"one": "1 item", "other": "# items"}
+
+	require.Equal(t, "1 item", translator.PluralInline("en", 1, forms))
+	require.Equal(t, "3 items", translator.PluralInline("en", 3, forms))
+
+	// Polish has a "few" category, which is missing from forms.
+	require.Equal(t, "3 items", translator.PluralInline("pl", 3, forms))
+}
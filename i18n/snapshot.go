@@ -0,0 +1,214 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"text/template"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/nicksnyder/go-i18n/i18n/language"
+	"github.com/nicksnyder/go-i18n/i18n/translation"
+)
+
+// TranslatorState is the state of a Translator, as returned by Snapshot.
+type TranslatorState struct {
+	bundle             *bundle.Bundle
+	flagged            []*flaggedBundle
+	environmentBundles map[string]*bundle.Bundle
+	environment        string
+	translateFuncs     map[string]bundle.TranslateFunc
+	icuMessages        map[string]map[string]*icuMessage
+	bucketMessages     map[string]map[string]*bucketMessage
+	htmlTranslations   map[string]map[string]map[string]bool
+	deprecations       map[string]*deprecation
+	displayNames       map[string]string
+	funcs              map[string]template.FuncMap
+	formatter          Formatter
+	middleware         []Middleware
+}
+
+// Snapshot returns the current state of t, i.e. its translations, including
+// those added with AddLanguage, the environment, the deprecated ids, the
+// display names, the template funcs, the Formatter and the middleware. Use
+// Restore to revert t to it, e.g. between tests.
+func (t *Translator) Snapshot() *TranslatorState {
+	t.mu.RLock()
+	s := &TranslatorState{
+		bundle:             copyBundle(t.bundle),
+		flagged:            copyFlaggedBundles(t.flagged),
+		environmentBundles: copyBundles(t.environmentBundles),
+		environment:        t.environment,
+		icuMessages:        copyICUMessages(t.icuMessages),
+		bucketMessages:     copyBucketMessages(t.bucketMessages),
+		htmlTranslations:   copyHTMLTranslations(t.htmlTranslations),
+		deprecations:       make(map[string]*deprecation),
+		displayNames:       make(map[string]string),
+		formatter:          t.formatter,
+		middleware:         t.middleware,
+	}
+	s.translateFuncs = make(map[string]bundle.TranslateFunc)
+	for lang, f := range t.translateFuncs {
+		s.translateFuncs[lang] = f
+	}
+	for id, d := range t.deprecations {
+		s.deprecations[id] = d
+	}
+	for lang, name := range t.displayNames {
+		s.displayNames[lang] = name
+	}
+	t.mu.RUnlock()
+
+	t.funcsMu.RLock()
+	s.funcs = copyTemplateFuncs(t.funcs)
+	t.funcsMu.RUnlock()
+
+	return s
+}
+
+// Restore reverts t to the state s returned by Snapshot. The same state may
+// be restored any number of times, but not while t is in use.
+func (t *Translator) Restore(s *TranslatorState) {
+	t.mu.Lock()
+	t.bundle = copyBundle(s.bundle)
+	t.flagged = copyFlaggedBundles(s.flagged)
+	t.environmentBundles = copyBundles(s.environmentBundles)
+	t.environment = s.environment
+	t.icuMessages = copyICUMessages(s.icuMessages)
+	t.bucketMessages = copyBucketMessages(s.bucketMessages)
+	t.htmlTranslations = copyHTMLTranslations(s.htmlTranslations)
+	t.translateFuncs = make(map[string]bundle.TranslateFunc)
+	for lang, f := range s.translateFuncs {
+		t.translateFuncs[lang] = f
+	}
+	t.deprecations = make(map[string]*deprecation)
+	for id, d := range s.deprecations {
+		t.deprecations[id] = d
+	}
+	t.displayNames = make(map[string]string)
+	for lang, name := range s.displayNames {
+		t.displayNames[lang] = name
+	}
+	t.formatter = s.formatter
+	t.middleware = s.middleware
+	t.mu.Unlock()
+
+	t.funcsMu.Lock()
+	t.funcs = copyTemplateFuncs(s.funcs)
+	t.funcsMu.Unlock()
+
+	t.templatesMu.Lock()
+	t.templates = nil
+	t.templatesMu.Unlock()
+}
+
+func copyTemplateFuncs(funcs map[string]template.FuncMap) map[string]template.FuncMap {
+	c := make(map[string]template.FuncMap)
+	for lang, m := range funcs {
+		c[lang] = make(template.FuncMap)
+		for name, fn := range m {
+			c[lang][name] = fn
+		}
+	}
+	return c
+}
+
+// copyBundle returns a bundle with copies of the translations of b.
+func copyBundle(b *bundle.Bundle) *bundle.Bundle {
+	c := bundle.New()
+	for tag, translations := range b.Translations() {
+		langs := language.Parse(tag)
+		if len(langs) == 0 {
+			continue
+		}
+		for _, tr := range translations {
+			c.AddTranslation(langs[0], copyTranslation(tr))
+		}
+	}
+	return c
+}
+
+// copyTranslation returns a copy of tr, which has its templates escaped.
+// The bundles modify the translations they hold when another one with the
+// same id is added, so they cannot be shared.
+func copyTranslation(tr translation.Translation) translation.Translation {
+	entry := map[string]interface{}{"id": tr.ID()}
+	if forms := pluralForms(tr); forms != nil {
+		m := make(map[string]interface{})
+		for category, src := range forms {
+			m[string(category)] = escapeTemplate(src)
+		}
+		entry["translation"] = m
+	} else if src, found := templateSource(tr, language.Other); found {
+		entry["translation"] = escapeTemplate(src)
+	}
+
+	c, err := translation.NewTranslation(entry)
+	if err != nil {
+		// Not expected, as go-i18n parses nothing in escaped templates.
+		return tr
+	}
+	return c
+}
+
+func copyBundles(bundles map[string]*bundle.Bundle) map[string]*bundle.Bundle {
+	c := make(map[string]*bundle.Bundle)
+	for key, b := range bundles {
+		c[key] = copyBundle(b)
+	}
+	return c
+}
+
+func copyFlaggedBundles(flagged []*flaggedBundle) []*flaggedBundle {
+	c := make([]*flaggedBundle, len(flagged))
+	for i, fb := range flagged {
+		c[i] = &flaggedBundle{flags: fb.flags, bundle: copyBundle(fb.bundle)}
+	}
+	return c
+}
+
+func copyICUMessages(messages map[string]map[string]*icuMessage) map[string]map[string]*icuMessage {
+	c := make(map[string]map[string]*icuMessage)
+	for lang, m := range messages {
+		c[lang] = make(map[string]*icuMessage)
+		for id, msg := range m {
+			c[lang][id] = msg
+		}
+	}
+	return c
+}
+
+func copyHTMLTranslations(sets map[string]map[string]map[string]bool) map[string]map[string]map[string]bool {
+	c := make(map[string]map[string]map[string]bool)
+	for key, langs := range sets {
+		c[key] = make(map[string]map[string]bool)
+		for lang, ids := range langs {
+			c[key][lang] = make(map[string]bool)
+			for id := range ids {
+				c[key][lang][id] = true
+			}
+		}
+	}
+	return c
+}
+
+func copyBucketMessages(messages map[string]map[string]*bucketMessage) map[string]map[string]*bucketMessage {
+	c := make(map[string]map[string]*bucketMessage)
+	for lang, m := range messages {
+		c[lang] = make(map[string]*bucketMessage)
+		for id, msg := range m {
+			c[lang][id] = msg
+		}
+	}
+	return c
+}
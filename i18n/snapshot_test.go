@@ -0,0 +1,69 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.yaml", `- id: "hello"
+  translation: "Hello, {{ .Name }}!"
+- id: "greeting"
+  translation: "Hi!"
+`})
+
+	check := func() {
+		require.Equal(t, "Hello, Steve!", tr.Func("en")("hello", map[string]interface{}{"Name": "Steve"}))
+		require.Equal(t, "Hello, Steve!", tr.Func("fr")("hello", map[string]interface{}{"Name": "Steve"}))
+		require.Equal(t, "English", tr.LanguageInfo("en").DisplayName)
+		require.Equal(t, "1,000", tr.FormatNumber("en", 1000, 0))
+	}
+
+	state := tr.Snapshot()
+	check()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, tr.AddTemplateFunc("", "upper", func(s string) string { return "STEVE" }))
+		require.NoError(t, tr.AddLanguage("en", []byte(`- id: "hello"
+  translation: "Hello, {{ upper .Name }}!"
+- id: "welcome"
+  deprecated: "greeting"
+- id: "icu"
+  format: icu
+  translation: "Hi {name}"
+`)))
+		require.NoError(t, tr.AddLanguage("fr", []byte(`- id: "hello"
+  translation: "Bonjour, {{ .Name }} !"
+`)))
+		tr.SetDisplayName("en", "Anglais")
+		tr.SetFormatter(stubFormatter{})
+		tr.AddMiddleware(func(next ResolveFunc) ResolveFunc { return next })
+
+		require.Equal(t, "Hello, STEVE!", tr.Func("en")("hello", map[string]interface{}{"Name": "Steve"}))
+		require.Equal(t, "Bonjour, Steve !", tr.Func("fr")("hello", map[string]interface{}{"Name": "Steve"}))
+		require.Equal(t, "Hi!", tr.Func("en")("welcome"))
+		require.Equal(t, "Hi Steve", tr.Func("en")("icu", map[string]interface{}{"name": "Steve"}))
+
+		tr.Restore(state)
+		require.Empty(t, tr.middleware)
+		check()
+		require.Equal(t, "", tr.Func("en")("welcome"))
+		require.Equal(t, "", tr.Func("en")("icu", map[string]interface{}{"name": "Steve"}))
+		_, found := tr.Raw("fr", "hello")
+		require.False(t, found)
+	}
+}
@@ -0,0 +1,472 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/nicksnyder/go-i18n/i18n/language"
+	"github.com/nicksnyder/go-i18n/i18n/translation"
+	"github.com/spf13/cast"
+)
+
+// go-i18n parses translation templates without any template funcs. So the
+// templates are kept in the bundles with their braces escaped, which makes
+// go-i18n return the source of the template it selects for the plural
+// category, and they are executed by the Translator, with its funcs.
+const escapedBrace = "\x00{"
+
+func escapeTemplate(src string) string {
+	return strings.Replace(src, "{", escapedBrace, -1)
+}
+
+func unescapeTemplate(src string) string {
+	return strings.Replace(src, escapedBrace, "{", -1)
+}
+
+// newTranslation creates a translation from entry, as decoded from a
+// translation file, checking that its templates parse.
+func (t *Translator) newTranslation(entry map[string]interface{}) (translation.Translation, error) {
+	escaped := make(map[string]interface{}, len(entry))
+	for k, v := range entry {
+		escaped[k] = v
+	}
+
+	switch v := entry["translation"].(type) {
+	case nil:
+		// Kept as explicitly empty, e.g. to tell it from a missing id
+		// in the coverage.
+		escaped["translation"] = ""
+	case string:
+		if err := t.checkTemplate(v); err != nil {
+			return nil, err
+		}
+		escaped["translation"] = escapeTemplate(v)
+	case map[string]interface{}, map[interface{}]interface{}:
+		forms := make(map[string]interface{})
+		for category, src := range toStringMap(v) {
+			s, ok := src.(string)
+			if !ok {
+				forms[category] = src
+				continue
+			}
+			if err := t.checkTemplate(s); err != nil {
+				return nil, err
+			}
+			forms[category] = escapeTemplate(s)
+		}
+		escaped["translation"] = forms
+	}
+
+	return translation.NewTranslation(escaped)
+}
+
+// escapeTranslation returns tr, loaded by go-i18n, with its templates escaped.
+func (t *Translator) escapeTranslation(tr translation.Translation) (translation.Translation, error) {
+	entry := map[string]interface{}{"id": tr.ID()}
+	if forms := pluralForms(tr); forms != nil {
+		m := make(map[string]interface{})
+		for category, src := range forms {
+			m[string(category)] = src
+		}
+		entry["translation"] = m
+	} else if src, found := templateSource(tr, language.Other); found {
+		entry["translation"] = src
+	}
+	return t.newTranslation(entry)
+}
+
+// escapeBundle returns a copy of b, loaded by go-i18n, with the templates escaped.
+func (t *Translator) escapeBundle(b *bundle.Bundle) *bundle.Bundle {
+	escaped := bundle.New()
+	for tag, translations := range b.Translations() {
+		langs := language.Parse(tag)
+		if len(langs) == 0 {
+			continue
+		}
+		var trs []translation.Translation
+		for _, tr := range translations {
+			etr, err := t.escapeTranslation(tr)
+			if err != nil {
+				t.logger.ERROR.Printf("Failed to parse translation %q in language %q: %s", tr.ID(), tag, err)
+				continue
+			}
+			trs = append(trs, etr)
+		}
+		escaped.AddTranslation(langs[0], trs...)
+	}
+	return escaped
+}
+
+// templateSource returns the source of the template for the plural
+// category p in tr, if any.
+func templateSource(tr translation.Translation, p language.Plural) (string, bool) {
+	tmpl := tr.Template(p)
+	if tmpl == nil {
+		return "", false
+	}
+	return unescapeTemplate(tmpl.String()), true
+}
+
+// AddTemplateFunc makes fn available as name in the translation templates of
+// lang, or of all languages if lang is empty. The funcs added for lang
+// take precedence, and all of them over the built-in ones, e.g. ordinal.
+func (t *Translator) AddTemplateFunc(lang, name string, fn interface{}) error {
+	if v := reflect.ValueOf(fn); v.Kind() != reflect.Func ||
+		v.Type().NumOut() < 1 || v.Type().NumOut() > 2 ||
+		v.Type().NumOut() == 2 && v.Type().Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
+		return fmt.Errorf("Invalid template func %q: must return one value, or a value and an error", name)
+	}
+
+	t.funcsMu.Lock()
+	if t.funcs == nil {
+		t.funcs = make(map[string]template.FuncMap)
+	}
+	if t.funcs[lang] == nil {
+		t.funcs[lang] = make(template.FuncMap)
+	}
+	t.funcs[lang][name] = fn
+	t.funcsMu.Unlock()
+
+	// The cached templates are parsed with the old funcs.
+	t.templatesMu.Lock()
+	t.templates = nil
+	t.templatesMu.Unlock()
+
+	return nil
+}
+
+// templateSources returns the template sources of tr by plural category,
+// with only the "other" category for single translations.
+func templateSources(tr translation.Translation) map[language.Plural]string {
+	if forms := pluralForms(tr); forms != nil {
+		return forms
+	}
+	if src, found := templateSource(tr, language.Other); found {
+		return map[language.Plural]string{language.Other: src}
+	}
+	return nil
+}
+
+// walkTemplate calls fn for node and all the nodes below it.
+func walkTemplate(node parse.Node, fn func(parse.Node)) {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+	fn(node)
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, c := range n.Nodes {
+			walkTemplate(c, fn)
+		}
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkTemplate(n.Pipe, fn)
+	case *parse.PipeNode:
+		for _, v := range n.Decl {
+			walkTemplate(v, fn)
+		}
+		for _, c := range n.Cmds {
+			walkTemplate(c, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplate(arg, fn)
+		}
+	case *parse.ChainNode:
+		walkTemplate(n.Node, fn)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(parse.Node)) {
+	walkTemplate(n.Pipe, fn)
+	walkTemplate(n.List, fn)
+	walkTemplate(n.ElseList, fn)
+}
+
+// templateFuncs returns the funcs available in translations in lang. If
+// lang is empty, the funcs added for any language are included.
+func (t *Translator) templateFuncs(lang string) template.FuncMap {
+	funcs := template.FuncMap{
+		"ordinal": func(n interface{}) string {
+			return t.Ordinal(lang, n)
+		},
+		"raw": raw,
+	}
+
+	t.funcsMu.RLock()
+	defer t.funcsMu.RUnlock()
+
+	for name, fn := range t.funcs[""] {
+		funcs[name] = fn
+	}
+	for l, m := range t.funcs {
+		if l != "" && (lang == "" || l == lang) {
+			for name, fn := range m {
+				funcs[name] = fn
+			}
+		}
+	}
+
+	return funcs
+}
+
+// checkTemplate reports whether src parses as a translation template, with
+// the funcs of any language.
+func (t *Translator) checkTemplate(src string) error {
+	_, err := t.parseWithAllFuncs(src)
+	return err
+}
+
+// parseWithAllFuncs parses src with the funcs of any language, as when it
+// is loaded, e.g. to inspect it. The templates are not cached.
+func (t *Translator) parseWithAllFuncs(src string) (*template.Template, error) {
+	masked, _ := maskFences(src)
+	return template.New("").Funcs(t.templateFuncs("")).Parse(masked)
+}
+
+// fenceRe matches text between backtick fences.
+var fenceRe = regexp.MustCompile("(?s)```.*?```")
+
+// maskFences replaces the text between backtick fences in src, fences
+// included, with placeholders, so it is not interpolated, e.g. code samples
+// containing "{{". It returns src masked and the fenced texts.
+func maskFences(src string) (string, []string) {
+	if !strings.Contains(src, "```") {
+		return src, nil
+	}
+
+	var fences []string
+	masked := fenceRe.ReplaceAllStringFunc(src, func(fence string) string {
+		fences = append(fences, fence)
+		return fencePlaceholder(len(fences) - 1)
+	})
+	return masked, fences
+}
+
+// unmaskFences puts the fenced texts back in s, an executed template.
+// They may be repeated, e.g. in a range.
+func unmaskFences(s string, fences []string) string {
+	for i, fence := range fences {
+		s = strings.Replace(s, fencePlaceholder(i), fence, -1)
+	}
+	return s
+}
+
+func fencePlaceholder(i int) string {
+	return "\x00fence" + strconv.Itoa(i) + "\x00"
+}
+
+// insertFunc is the template func every action in a translation template
+// pipes its value to, so the values inserted into translations can be
+// formatted and counted.
+const insertFunc = "i18nInsert"
+
+// parseTemplate parses src with the template funcs bound to lang. The
+// templates are cached.
+func (t *Translator) parseTemplate(lang, src string) (*template.Template, error) {
+	key := lang + "\x00" + src
+
+	t.templatesMu.Lock()
+	defer t.templatesMu.Unlock()
+
+	if tmpl, found := t.templates[key]; found {
+		return tmpl, nil
+	}
+
+	funcs := t.templateFuncs(lang)
+	funcs[insertFunc] = t.insertValue(lang, "", false, nil)
+
+	tmpl, err := template.New("").Funcs(funcs).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	walkTemplate(tmpl.Tree.Root, func(node parse.Node) {
+		// Actions declaring variables insert nothing.
+		if a, ok := node.(*parse.ActionNode); ok && len(a.Pipe.Decl) == 0 {
+			a.Pipe.Cmds = append(a.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      a.Pos,
+				Args:     []parse.Node{parse.NewIdentifier(insertFunc).SetPos(a.Pos)},
+			})
+		}
+	})
+	if t.templates == nil {
+		t.templates = make(map[string]*template.Template)
+	}
+	t.templates[key] = tmpl
+	return tmpl, nil
+}
+
+// insertValue returns the func that prepares values to be inserted into
+// translationID in lang, counting them in substitutions if it is not nil.
+// Long strings are truncated, and numbers are formatted for lang, unless
+// disableTranslationNumberFormatting is set. Values inserted into an HTML
+// translation are escaped.
+func (t *Translator) insertValue(lang, translationID string, isHTML bool, substitutions *int) func(v interface{}) interface{} {
+	return func(v interface{}) interface{} {
+		countSubstitution(substitutions)
+		v = t.truncateValue(translationID, v)
+		if !t.cfg.GetBool("disableTranslationNumberFormatting") {
+			if s, ok := t.formatArg(lang, v); ok {
+				v = s
+			}
+		}
+		if isHTML {
+			v = escapeHTMLValue(v)
+		}
+		return v
+	}
+}
+
+func countSubstitution(substitutions *int) {
+	if substitutions != nil {
+		*substitutions++
+	}
+}
+
+// execute executes the escaped template src of translationID, as returned by
+// a go-i18n translate func called with args, in lang. The args are only ever
+// data to the template, and their values inserted as is, never parsed. Text
+// between backtick fences is not interpolated. If opts has substitutions,
+// it is incremented for every value inserted. Template func usage is
+// recorded, if enabled, unless opts is quiet. If isHTML is set, the values
+// inserted are escaped, and the output checked.
+func (t *Translator) execute(lang, translationID, src string, isHTML bool, opts resolveOptions, args []interface{}) (string, error) {
+	src = unescapeTemplate(src)
+	if t.cfg.GetBool("localizeQuotes") {
+		// Before the args are inserted, so only the quotes of the
+		// translation itself are localized.
+		src = t.localizeQuotes(lang, src, templateOpaqueLen)
+	}
+	if !strings.Contains(src, "{{") {
+		return src, nil
+	}
+
+	src, fences := maskFences(src)
+	tmpl, err := t.parseTemplate(lang, src)
+	if err != nil {
+		return "", err
+	}
+
+	recordUsage := t.cfg.GetBool("recordTemplateFuncUsage") && !opts.quiet
+	truncate := t.cfg.GetInt("maxTranslationArgLength") > 0
+	if opts.substitutions != nil || recordUsage || truncate || isHTML {
+		// The cached template may be executed concurrently, so the
+		// counter, the translation id to warn about, the escaping and
+		// the recording funcs are bound to a copy.
+		if tmpl, err = tmpl.Clone(); err != nil {
+			return "", err
+		}
+		funcs := template.FuncMap{insertFunc: t.insertValue(lang, translationID, isHTML, opts.substitutions)}
+		if recordUsage {
+			for name, fn := range t.templateFuncs(lang) {
+				funcs[name] = t.recordingFunc(translationID, name, fn)
+			}
+		}
+		tmpl.Funcs(funcs)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData(args)); err != nil {
+		return "", err
+	}
+	executed := unmaskFences(buf.String(), fences)
+
+	if isHTML {
+		// The checked template may still execute to unsafe HTML, e.g. a
+		// javascript: URL inserted into an href.
+		checked, problems := t.checkHTML(executed)
+		if len(problems) > 0 {
+			t.logger.WARN.Printf("Stripped disallowed HTML from translation %q in language %q: %s", translationID, lang, strings.Join(problems, ", "))
+		}
+		return checked, nil
+	}
+	return executed, nil
+}
+
+// templateData returns the data a translation template is executed with
+// for the given translate func args, the way go-i18n does it: the first
+// arg is the count if it is a number, else the data. The count is
+// available as .Count.
+func templateData(args []interface{}) interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+
+	switch args[0].(type) {
+	case int, int8, int16, int32, int64, string:
+	default:
+		return args[0]
+	}
+
+	count := args[0]
+	if len(args) < 2 || args[1] == nil {
+		return map[string]interface{}{"Count": count}
+	}
+
+	data := make(map[string]interface{})
+	for k, v := range toStringMap(args[1]) {
+		data[k] = v
+	}
+	data["Count"] = count
+	return data
+}
+
+// toStringMap returns the entries of a map, or the exported fields of a
+// struct, by name.
+func toStringMap(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	m := make(map[string]interface{})
+	switch rv.Kind() {
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			m[cast.ToString(k.Interface())] = rv.MapIndex(k).Interface()
+		}
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			if f := rv.Type().Field(i); f.PkgPath == "" {
+				m[f.Name] = rv.Field(i).Interface()
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TemplateError is an error executing the template of a translation.
+type TemplateError struct {
+	Lang string
+	ID   string
+	Err  error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("Failed to execute translation %q in language %q: %s", e.ID, e.Lang, e.Err)
+}
+
+// templateErrorsByID sorts template errors by language, then id.
+type templateErrorsByID []*TemplateError
+
+func (e templateErrorsByID) Len() int      { return len(e) }
+func (e templateErrorsByID) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e templateErrorsByID) Less(i, j int) bool {
+	if e[i].Lang != e[j].Lang {
+		return e[i].Lang < e[j].Lang
+	}
+	return e[i].ID < e[j].ID
+}
+
+// FuncE is like Func, but the translate func it returns also returns the
+// error translating, e.g. a *TemplateError if the template of the
+// translation failed, or the error for a missing translation if
+// missingTranslationBehavior is "error".
+func (t *Translator) FuncE(lang string) func(translationID string, args ...interface{}) (string, error) {
+	return func(translationID string, args ...interface{}) (string, error) {
+		var execErr error
+		translated, _, err := t.resolve(lang, translationID, resolveOptions{execErr: &execErr}, args...)
+		if err != nil {
+			return translated, err
+		}
+		return translated, execErr
+	}
+}
+
+// TemplateErrors returns the template errors of the translations rendered
+// so far, if collectTemplateErrors is set, e.g. to report every broken
+// translation of a build at once instead of failing on the first. Every
+// translation is reported once per language, with its first error. They are
+// sorted by language and id.
+func (t *Translator) TemplateErrors() []*TemplateError {
+	t.templateErrorsMu.Lock()
+	defer t.templateErrorsMu.Unlock()
+
+	errs := make([]*TemplateError, 0, len(t.templateErrors))
+	for _, err := range t.templateErrors {
+		errs = append(errs, err)
+	}
+	sort.Sort(templateErrorsByID(errs))
+	return errs
+}
+
+func (t *Translator) addTemplateError(err *TemplateError) {
+	t.templateErrorsMu.Lock()
+	defer t.templateErrorsMu.Unlock()
+
+	if t.templateErrors == nil {
+		t.templateErrors = make(map[string]*TemplateError)
+	}
+	key := err.Lang + "\x00" + err.ID
+	if _, found := t.templateErrors[key]; !found {
+		t.templateErrors[key] = err
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateErrors(t *testing.T) {
+	files := []testFile{
+		{"en.yaml", `- id: "first"
+  translation: "First: {{ index .Items 3 }}"
+- id: "count"
+  translation: "{{ len .Count }} items"
+- id: "fine"
+  translation: "Fine"
+`},
+		{"de.yaml", `- id: "first"
+  translation: "Erstes: {{ index .Items 5 }}"
+`},
+	}
+	data := map[string]interface{}{"Items": []string{"a"}, "Count": 2}
+
+	v := newTestConfig()
+	translator := newTranslatorFromFiles(t, v, files...)
+	translator.Func("en")("first", data)
+	require.Empty(t, translator.TemplateErrors())
+
+	v.Set("collectTemplateErrors", true)
+	translator = newTranslatorFromFiles(t, v, files...)
+
+	en := translator.FuncE("en")
+	translated, err := en("first", data)
+	require.Error(t, err)
+	require.Equal(t, "", translated)
+	terr, ok := err.(*TemplateError)
+	require.True(t, ok)
+	require.Equal(t, "first", terr.ID)
+
+	_, err = en("first", data)
+	require.Error(t, err)
+	_, err = en("count", data)
+	require.Error(t, err)
+	translated, err = en("fine")
+	require.NoError(t, err)
+	require.Equal(t, "Fine", translated)
+	translator.Func("de")("first", data)
+
+	errs := translator.TemplateErrors()
+	require.Len(t, errs, 3)
+	for i, expected := range []struct{ lang, id string }{
+		{"de", "first"},
+		{"en", "count"},
+		{"en", "first"},
+	} {
+		require.Equal(t, expected.lang, errs[i].Lang)
+		require.Equal(t, expected.id, errs[i].ID)
+		require.Error(t, errs[i].Err)
+	}
+	require.Contains(t, errs[0].Error(), `Failed to execute translation "first" in language "de"`)
+}
@@ -0,0 +1,176 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Templates range over maps in key order, so no sorting option is needed
+// for reproducible output.
+func TestTemplateRangeMapOrder(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.yaml", `- id: "scores"
+  translation: "{{ range $name, $score := .Scores }}{{ $name }}={{ $score }};{{ end }}"
+`})
+
+	scores := make(map[string]int)
+	for i := 0; i < 26; i++ {
+		scores[string(rune('z'-i))] = i
+	}
+
+	var expected string
+	for i := 25; i >= 0; i-- {
+		expected += fmt.Sprintf("%c=%d;", 'z'-i, i)
+	}
+
+	for i := 0; i < 20; i++ {
+		require.Equal(t, expected, tr.Func("en")("scores", map[string]interface{}{"Scores": scores}))
+	}
+}
+
+func TestTemplateArgsInsertedLiterally(t *testing.T) {
+	v := newTestConfig()
+	v.Set("translationFlags", []string{"beta"})
+
+	tr := newTranslatorFromFiles(t, v,
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello, {{ .Name }}!"
+- id: "items"
+  translation:
+    one: "One item for {{ .Name }}"
+    other: "{{ .Count }} items for {{ .Name }}"
+- id: "icu"
+  format: icu
+  translation: "Hi {Name}"
+- id: "beta"
+  flags: [beta]
+  translation: "Beta {{ .Name }}"
+`},
+		testFile{"de.yaml", `- id: "other"
+  translation: "Anderes"
+`})
+
+	evil := map[string]interface{}{"Name": "{{.Evil}}", "Evil": "pwned"}
+
+	for i, test := range []struct {
+		lang     string
+		id       string
+		args     []interface{}
+		expected string
+	}{
+		{"en", "hello", []interface{}{evil}, "Hello, {{.Evil}}!"},
+		{"de", "hello", []interface{}{evil}, "Hello, {{.Evil}}!"},
+		{"en", "items", []interface{}{3, evil}, "3 items for {{.Evil}}"},
+		{"en", "icu", []interface{}{evil}, "Hi {{.Evil}}"},
+		{"en", "beta", []interface{}{evil}, "Beta {{.Evil}}"},
+	} {
+		require.Equal(t, test.expected, tr.Func(test.lang)(test.id, test.args...), fmt.Sprintf("[%d] %s", i, test.id))
+	}
+
+	require.Equal(t, template.HTML("Hello, {{.Evil}}!"), tr.FuncHTML("en")("hello", evil))
+}
+
+func TestTemplateNumbersFormatted(t *testing.T) {
+	files := []testFile{
+		{"en.yaml", `- id: "wordCount"
+  translation: "This article has {{ .WordCount }} words."
+- id: "readingTime"
+  translation:
+    one: "One minute read"
+    other: "{{ .Count }} minutes read"
+- id: "icu"
+  format: icu
+  translation: "{count, plural, one{# view} other{# views}}"
+- id: "exact"
+  translation: "{{ .Big }} / {{ .Max }} / {{ .Small }}"
+`},
+		{"sv.yaml", `- id: "wordCount"
+  translation: "Artikeln har {{ .WordCount }} ord."
+`},
+		{"de.yaml", `- id: "wordCount"
+  translation: "Dieser Artikel hat {{ .WordCount }} Wörter."
+- id: "ratio"
+  translation: "{{ .Ratio }} / {{ .Version }} / {{ .Place | ordinal }} / {{ .Duration }}"
+- id: "copyright"
+  translation: "© {{ raw .Year }}, {{ raw .Ratio }}"
+- id: "page"
+  format: html
+  translation: "<a href=\"/page/{{ .ID | raw }}\">Seite {{ .ID }}</a>"
+`},
+	}
+
+	data := map[string]interface{}{
+		"WordCount": 1000,
+		"Ratio":     1234.5,
+		"Version":   "1000",
+		"Place":     1000,
+		"Duration":  1500 * time.Millisecond,
+		"Big":       int64(9007199254740993),
+		"Max":       uint64(18446744073709551615),
+		"Small":     float32(0.1),
+		"Year":      2017,
+		"ID":        12345,
+	}
+
+	tr := newTranslatorFromFiles(t, newTestConfig(), files...)
+
+	require.Equal(t, "This article has 1,000 words.", tr.Func("en")("wordCount", data))
+	require.Equal(t, "Dieser Artikel hat 1.000 Wörter.", tr.Func("de")("wordCount", data))
+	require.Equal(t, "1,500 minutes read", tr.Func("en")("readingTime", 1500))
+	require.Equal(t, "12,345 views", tr.Func("en")("icu", 12345))
+	require.Equal(t, "1.234,5 / 1000 / 1000. / 1.5s", tr.Func("de")("ratio", data))
+	require.Equal(t, "9,007,199,254,740,993 / 18,446,744,073,709,551,615 / 0.1", tr.Func("en")("exact", data))
+
+	// Numbers piped to raw are inserted as is.
+	require.Equal(t, "© 2017, 1234.5", tr.Func("de")("copyright", data))
+	require.Equal(t, `<a href="/page/12345">Seite 12.345</a>`, tr.Func("de")("page", data))
+
+	// There is no locale data for Swedish.
+	require.Equal(t, "Artikeln har 1000 ord.", tr.Func("sv")("wordCount", data))
+
+	v := newTestConfig()
+	v.Set("formattingFallbackLocale", "de")
+	tr = newTranslatorFromFiles(t, v, files...)
+	require.Equal(t, "Artikeln har 1.000 ord.", tr.Func("sv")("wordCount", data))
+
+	v = newTestConfig()
+	v.Set("disableTranslationNumberFormatting", true)
+	tr = newTranslatorFromFiles(t, v, files...)
+
+	require.Equal(t, "This article has 1000 words.", tr.Func("en")("wordCount", data))
+	require.Equal(t, "Dieser Artikel hat 1000 Wörter.", tr.Func("de")("wordCount", data))
+	require.Equal(t, "12345 views", tr.Func("en")("icu", 12345))
+}
+
+func TestTemplateFencesNotInterpolated(t *testing.T) {
+	tr := newTranslatorFromFiles(t, newTestConfig(), testFile{"en.yaml", `- id: "usage"
+  translation: "Hi {{ .Name }}, print the title with ` + "```{{ .Title }}```" + `, or ` + "```{{ range .Pages }}```" + `."
+- id: "repeated"
+  translation: "{{ range .Names }}{{ . }}: ` + "```{{ . }}```" + ` {{ end }}"
+- id: "unclosed"
+  translation: "{{ .Name }} ` + "```" + `"
+`})
+
+	data := map[string]interface{}{"Name": "Steve", "Title": "Hugo", "Names": []string{"a", "b"}}
+
+	require.Equal(t, "Hi Steve, print the title with ```{{ .Title }}```, or ```{{ range .Pages }}```.", tr.Func("en")("usage", data))
+	require.Equal(t, "a: ```{{ . }}``` b: ```{{ . }}``` ", tr.Func("en")("repeated", data))
+	require.Equal(t, "Steve ```", tr.Func("en")("unclosed", data))
+	require.Equal(t, []string{"Name"}, tr.Variables("usage"))
+}
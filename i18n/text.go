@@ -0,0 +1,238 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// Capitalize title cases the first letter of s using the casing rules of
+// lang, e.g. "istanbul" becomes "İstanbul" in Turkish and "ǆep" becomes
+// "ǅep". Letters without a single title case form, like "ß", are left as
+// is, as is the rest of s.
+func (t *Translator) Capitalize(lang string, s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	base, _ := language.Make(lang).Base()
+	switch base.String() {
+	case "tr", "az":
+		r = unicode.TurkishCase.ToTitle(r)
+	default:
+		r = unicode.ToTitle(r)
+	}
+	return string(r) + s[size:]
+}
+
+// newlinesRe matches line breaks and the blanks around them.
+var newlinesRe = regexp.MustCompile(`[ \t]*\r?\n[ \t\r\n]*`)
+
+// filterOutput applies the output filters to s, a translation resolved in
+// lang.
+func (t *Translator) filterOutput(lang, s string) string {
+	return t.normalizeNewlines(s)
+}
+
+// normalizeNewlines normalizes the newlines in s, a resolved translation,
+// as set in translationNewlines, e.g. for multiline YAML values ending in a
+// newline, which add white space to HTML:
+//
+//	keep               leaves s as is, the default
+//	strip-trailing     removes trailing newlines
+//	collapse-to-space  also replaces the other line breaks with a space
+func (t *Translator) normalizeNewlines(s string) string {
+	switch t.cfg.GetString("translationNewlines") {
+	case "strip-trailing":
+		return strings.TrimRight(s, "\r\n")
+	case "collapse-to-space":
+		return newlinesRe.ReplaceAllString(strings.TrimRight(s, "\r\n"), " ")
+	}
+	return s
+}
+
+// Truncate shortens s to at most maxGraphemes user-perceived characters,
+// followed by the ellipsis of lang, e.g. "…", if anything was cut. It never
+// splits a character made of more than one code point, e.g. an emoji with a
+// skin tone or a letter with a combining accent, unlike truncating on runes.
+func (t *Translator) Truncate(lang string, s string, maxGraphemes int) string {
+	i, n := 0, 0
+	for i < len(s) && n < maxGraphemes {
+		i += graphemeLen(s[i:])
+		n++
+	}
+	if i == len(s) {
+		return s
+	}
+
+	ellipsis := t.locales.find(lang).ellipsis
+	if ellipsis == "" {
+		ellipsis = "…"
+	}
+	return s[:i] + ellipsis
+}
+
+const zeroWidthJoiner = '\u200d'
+
+// graphemeLen returns the length in bytes of the first grapheme cluster in
+// s, an approximation of the extended grapheme clusters of Unicode TR 29:
+// marks, emoji modifiers and tags belong to the character before them,
+// characters joined by a zero width joiner, e.g. in "👩‍💻", belong together,
+// as do the regional indicators of a flag and CR LF.
+func graphemeLen(s string) int {
+	r, i := utf8.DecodeRuneInString(s)
+	switch {
+	case r == '\r':
+		if i < len(s) && s[i] == '\n' {
+			i++
+		}
+		return i
+	case isRegionalIndicator(r):
+		if next, size := utf8.DecodeRuneInString(s[i:]); isRegionalIndicator(next) {
+			i += size
+		}
+	}
+
+	for i < len(s) {
+		next, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case next == zeroWidthJoiner:
+			i += size
+			if i < len(s) {
+				_, size = utf8.DecodeRuneInString(s[i:])
+				i += size
+			}
+		case isGraphemeExtend(next):
+			i += size
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isGraphemeExtend reports whether r belongs to the character before it.
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == '\u200c' ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) || // Emoji modifiers, i.e. skin tones.
+		(r >= 0xe0020 && r <= 0xe007f) // Tags, e.g. in subdivision flags.
+}
+
+var defaultQuotes = []string{"“", "”", "‘", "’"}
+
+// localizeQuotes replaces the straight quotes in s, the text of a
+// translation, with the quotation marks of lang, e.g. "«" and "»" in French,
+// those nested in others with the secondary ones, e.g. "‚" and "‘" in
+// German. A straight quote opens a quotation if it is at the start of s or
+// after white space or an opening bracket or quotation, else it closes the
+// innermost one. Apostrophes, e.g. in "don't", and the quotes in HTML tags
+// are left as is, and so are the runs of s that opaqueLen returns the length
+// of, e.g. template actions, which count as a word.
+func (t *Translator) localizeQuotes(lang, s string, opaqueLen func(s string) int) string {
+	quotes := t.locales.find(lang).quotes
+	if quotes == nil {
+		quotes = defaultQuotes
+	}
+	if !strings.ContainsAny(s, `"'`) {
+		return s
+	}
+
+	var (
+		buf bytes.Buffer
+		// The straight quotes of the open quotations, innermost last.
+		open []rune
+		prev rune = ' '
+	)
+	for i := 0; i < len(s); {
+		if n := opaqueLen(s[i:]); n > 0 {
+			buf.WriteString(s[i : i+n])
+			i += n
+			prev = 'x'
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		next, _ := utf8.DecodeRuneInString(s[i+size:])
+
+		if r == '<' && (unicode.IsLetter(next) || next == '/' || next == '!') {
+			end := strings.IndexByte(s[i:], '>')
+			if end >= 0 {
+				buf.WriteString(s[i : i+end+1])
+				i += end + 1
+				prev = '>'
+				continue
+			}
+		}
+
+		if r != '"' && r != '\'' {
+			buf.WriteRune(r)
+			i += size
+			prev = r
+			continue
+		}
+
+		opening := unicode.IsSpace(prev) || strings.ContainsRune("([{“‘«„‚「『\"'", prev)
+		switch {
+		case opening && next != utf8.RuneError && !unicode.IsSpace(next):
+			buf.WriteString(quotes[2*(len(open)%2)])
+			open = append(open, r)
+		case len(open) > 0 && open[len(open)-1] == r && !(r == '\'' && unicode.IsLetter(next)):
+			open = open[:len(open)-1]
+			buf.WriteString(quotes[2*(len(open)%2)+1])
+		default:
+			buf.WriteRune(r)
+		}
+		i += size
+		prev = r
+	}
+	return buf.String()
+}
+
+// templateOpaqueLen returns the length of the action or the fenced text at
+// the start of s, the source of a translation template, if any, so their
+// quotes are not localized.
+func templateOpaqueLen(s string) int {
+	for _, delims := range [][2]string{{"{{", "}}"}, {"```", "```"}} {
+		if !strings.HasPrefix(s, delims[0]) {
+			continue
+		}
+		if end := strings.Index(s[len(delims[0]):], delims[1]); end >= 0 {
+			return len(delims[0]) + end + len(delims[1])
+		}
+	}
+	return 0
+}
+
+// insertedOpaqueLen returns the length of the placeholder of an inserted
+// value at the start of s, if any.
+func insertedOpaqueLen(s string) int {
+	if !strings.HasPrefix(s, "\x00arg") {
+		return 0
+	}
+	if end := strings.IndexByte(s[1:], 0); end >= 0 {
+		return end + 2
+	}
+	return 0
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapitalize(t *testing.T) {
+	translator := newTestTranslator()
+
+	for i, test := range []struct {
+		lang, in, expected string
+	}{
+		{"en", "welcome home", "Welcome home"},
+		{"en", "iceland", "Iceland"},
+		{"tr", "istanbul", "İstanbul"},
+		{"tr", "ılık", "Ilık"},
+		{"de", "ärger", "Ärger"},
+		{"de", "ßtraße", "ßtraße"},
+		{"hr", "ǆep", "ǅep"},
+		{"en", "", ""},
+	} {
+		require.Equal(t, test.expected, translator.Capitalize(test.lang, test.in), fmt.Sprintf("[%d] %s", i, test.in))
+	}
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	files := []testFile{
+		{"en.yaml", `- id: "notice"
+  translation: |
+    Read this
+    before you start.
+
+- id: "terms"
+  format: html
+  translation: "Read the <a href=\"/terms\">terms</a>.\n\n"
+`},
+		{"fr.yaml", `- id: "other"
+  translation: "Autre"
+`},
+	}
+
+	for i, test := range []struct {
+		mode          string
+		notice, terms string
+	}{
+		{"", "Read this\nbefore you start.\n", "Read the <a href=\"/terms\">terms</a>.\n\n"},
+		{"keep", "Read this\nbefore you start.\n", "Read the <a href=\"/terms\">terms</a>.\n\n"},
+		{"strip-trailing", "Read this\nbefore you start.", "Read the <a href=\"/terms\">terms</a>."},
+		{"collapse-to-space", "Read this before you start.", "Read the <a href=\"/terms\">terms</a>."},
+	} {
+		v := newTestConfig()
+		v.Set("translationNewlines", test.mode)
+		translator := newTranslatorFromFiles(t, v, files...)
+
+		require.Equal(t, test.notice, translator.Func("en")("notice"), fmt.Sprintf("[%d] %s", i, test.mode))
+		require.Equal(t, test.notice, translator.Func("fr")("notice"), fmt.Sprintf("[%d] %s", i, test.mode))
+		require.Equal(t, test.terms, translator.Func("en")("terms"), fmt.Sprintf("[%d] %s", i, test.mode))
+		require.Equal(t, template.HTML(test.terms), translator.FuncHTML("fr")("terms"), fmt.Sprintf("[%d] %s", i, test.mode))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	translator := newTestTranslator()
+
+	for i, test := range []struct {
+		lang, in string
+		max      int
+		expected string
+	}{
+		{"en", "Hello, world", 5, "Hello…"},
+		{"en", "Hello", 5, "Hello"},
+		{"en", "Hello", 10, "Hello"},
+		{"en", "Hi 👩🏽‍💻 there", 4, "Hi 👩🏽‍💻…"},
+		{"en", "Hi 👩🏽‍💻 there", 3, "Hi …"},
+		{"en", "🇳🇴🇩🇪🇫🇷", 2, "🇳🇴🇩🇪…"},
+		{"fr", "Cafe\u0301 au lait", 4, "Cafe\u0301…"},
+		{"en", "a\r\nb", 2, "a\r\n…"},
+		{"zh", "你好，世界", 2, "你好……"},
+		{"en", "Hello", 0, "…"},
+		{"en", "", 0, ""},
+	} {
+		require.Equal(t, test.expected, translator.Truncate(test.lang, test.in, test.max), fmt.Sprintf("[%d] %s", i, test.in))
+	}
+}
+
+func TestLocalizeQuotes(t *testing.T) {
+	files := []testFile{
+		{"en.yaml", `- id: "said"
+  translation: "She said \"hi\" and left"
+- id: "nested"
+  translation: "He said \"she wrote 'don't' twice\" today"
+- id: "doubled"
+  translation: "\"Outer \"inner\" outer\""
+- id: "link"
+  format: html
+  translation: "Read \"<a href=\"/terms\">the terms</a>\""
+- id: "titled"
+  format: html
+  translation: "See \"<a href=\"{{ .URL }}\" title=\"{{ .Title }}\">{{ .Title }}</a>\""
+- id: "quoted"
+  translation: "\"{{ .Text }}\" by {{ .Author }}"
+- id: "fenced"
+  translation: "Write \"` + "```{{ .Title }} 'x'```" + `\" in the template"
+- id: "icu"
+  format: icu
+  translation: "{name} said \"{text}\""
+`},
+		{"fr.yaml", `- id: "said"
+  translation: "Elle a dit \"salut\" et est partie"
+- id: "nested"
+  translation: "Il a dit \"elle a écrit 'oui' deux fois\" aujourd'hui"
+`},
+		{"de.yaml", `- id: "said"
+  translation: "Sie sagte \"hallo\" und ging"
+- id: "nested"
+  translation: "Er sagte \"sie schrieb 'ja' zweimal\" heute"
+`},
+	}
+
+	translator := newTranslatorFromFiles(t, newTestConfig(), files...)
+	require.Equal(t, `She said "hi" and left`, translator.Func("en")("said"))
+
+	v := newTestConfig()
+	v.Set("localizeQuotes", true)
+	translator = newTranslatorFromFiles(t, v, files...)
+
+	for i, test := range []struct {
+		lang, id, expected string
+	}{
+		{"en", "said", "She said “hi” and left"},
+		{"en", "nested", "He said “she wrote ‘don't’ twice” today"},
+		{"en", "doubled", "“Outer ‘inner’ outer”"},
+		{"en", "link", "Read “<a href=\"/terms\">the terms</a>”"},
+		{"fr", "said", "Elle a dit «\u00a0salut\u00a0» et est partie"},
+		{"fr", "nested", "Il a dit «\u00a0elle a écrit “oui” deux fois\u00a0» aujourd'hui"},
+		{"de", "said", "Sie sagte „hallo“ und ging"},
+		{"de", "nested", "Er sagte „sie schrieb ‚ja‘ zweimal“ heute"},
+		{"it", "said", "She said “hi” and left"},
+	} {
+		require.Equal(t, test.expected, translator.Func(test.lang)(test.id), fmt.Sprintf("[%d] %s", i, test.expected))
+	}
+
+	// Only the quotes of the translation itself are localized.
+	en := translator.Func("en")
+	data := map[string]interface{}{
+		"URL":    "/about",
+		"Title":  `The "About" page`,
+		"Text":   `They said "no"`,
+		"Author": "O'Brien",
+		"name":   "Ann",
+		"text":   `"hi"`,
+	}
+	require.Equal(t, `See “<a href="/about" title="The &#34;About&#34; page">The &#34;About&#34; page</a>”`, en("titled", data))
+	require.Equal(t, `“They said "no"” by O'Brien`, en("quoted", data))
+	require.Equal(t, "Write “```{{ .Title }} 'x'```” in the template", en("fenced", data))
+	require.Equal(t, `Ann said “"hi"”`, en("icu", data))
+}
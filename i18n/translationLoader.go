@@ -0,0 +1,280 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/nicksnyder/go-i18n/i18n/language"
+	"github.com/nicksnyder/go-i18n/i18n/translation"
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v2"
+)
+
+// flaggedBundle holds translation variants that are only used when all of
+// its flags are active.
+type flaggedBundle struct {
+	flags  []string
+	bundle *bundle.Bundle
+}
+
+// bySpecificity sorts flagged bundles with the most flags first.
+type bySpecificity []*flaggedBundle
+
+func (s bySpecificity) Len() int           { return len(s) }
+func (s bySpecificity) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s bySpecificity) Less(i, j int) bool { return len(s[i].flags) > len(s[j].flags) }
+
+// addTranslationFile parses the given translation file and adds its
+// translations to t. Plural translations already loaded are merged
+// category by category, so a later source (e.g. the project) can add or
+// replace single plural forms of an entry defined in an earlier one (e.g. the theme).
+//
+// Entries with flags are variants of the entry with the same id, and are
+// kept apart from the other translations, as are the entries overriding
+// others in an environment. Entries marked as deprecated redirect their id
+// to another one.
+func (t *Translator) addTranslationFile(filename string, content []byte) error {
+	base := filepath.Base(filename)
+	langs := language.Parse(base)
+	if len(langs) != 1 {
+		return fmt.Errorf("Expected one language in filename %q, found %d", base, len(langs))
+	}
+	lang := langs[0]
+
+	entries, decoded, err := decodeTranslationFile(filename, content)
+	if err != nil {
+		return err
+	}
+
+	if !decoded {
+		// Leave other formats to go-i18n.
+		fileBundle := bundle.New()
+		if err := fileBundle.ParseTranslationFileBytes(filename, content); err != nil {
+			return err
+		}
+		var translations []translation.Translation
+		for _, tr := range fileBundle.Translations()[lang.Tag] {
+			etr, err := t.escapeTranslation(tr)
+			if err != nil {
+				return fmt.Errorf("Unable to parse translation %q in %q: %s", tr.ID(), base, err)
+			}
+			translations = append(translations, etr)
+		}
+		return mergeTranslations(t.bundle, lang, translations...)
+	}
+
+	var translations []translation.Translation
+	for i, entry := range entries {
+		id, err := t.checkID(base, cast.ToString(entry["id"]))
+		if err != nil {
+			return err
+		}
+		entry["id"] = id
+
+		if replacement := cast.ToString(entry["deprecated"]); replacement != "" {
+			t.addDeprecation(id, &deprecation{
+				replacement: replacement,
+				removeAfter: cast.ToString(entry["removeAfter"]),
+			})
+			continue
+		}
+
+		switch cast.ToString(entry["format"]) {
+		case "icu":
+			msg, err := parseICUMessage(cast.ToString(entry["translation"]))
+			if err != nil {
+				return fmt.Errorf("Unable to parse translation %q in %q: %s", id, base, err)
+			}
+			t.removeBucketMessage(lang.Tag, id)
+			t.setHTML(baseBundleKey, lang.Tag, id, false)
+			t.addICUMessage(lang.Tag, id, msg)
+			continue
+		case "buckets":
+			msg, err := t.parseBucketMessage(entry["translation"])
+			if err != nil {
+				return fmt.Errorf("Unable to parse translation %q in %q: %s", id, base, err)
+			}
+			t.removeICUMessage(lang.Tag, id)
+			t.setHTML(baseBundleKey, lang.Tag, id, false)
+			t.addBucketMessage(lang.Tag, id, msg)
+			continue
+		}
+		t.removeICUMessage(lang.Tag, id)
+		t.removeBucketMessage(lang.Tag, id)
+
+		isHTML := cast.ToString(entry["format"]) == "html"
+		if isHTML {
+			if err := t.checkHTMLEntry(base, entry); err != nil {
+				return err
+			}
+		}
+
+		tr, err := t.newTranslation(entry)
+		if err != nil {
+			return fmt.Errorf("Unable to parse translation #%d in %q: %s", i, base, err)
+		}
+
+		if env := cast.ToString(entry["environment"]); env != "" {
+			t.setHTML(environmentBundleKey(env), lang.Tag, id, isHTML)
+			if err := mergeTranslations(t.environmentBundle(env), lang, tr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if flags := cast.ToStringSlice(entry["flags"]); len(flags) > 0 {
+			t.setHTML(variantBundleKey(flags), lang.Tag, id, isHTML)
+			if err := mergeTranslations(t.variantBundle(flags), lang, tr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		t.setHTML(baseBundleKey, lang.Tag, id, isHTML)
+		translations = append(translations, tr)
+	}
+
+	return mergeTranslations(t.bundle, lang, translations...)
+}
+
+// checkID returns id with any leading and trailing whitespace trimmed, as
+// an id like " hello" is a copy-paste mistake that never matches, with a
+// warning. It fails instead if paddedTranslationIDs is "error".
+func (t *Translator) checkID(filename, id string) (string, error) {
+	trimmed := strings.TrimSpace(id)
+	if trimmed == id {
+		return id, nil
+	}
+	if t.cfg.GetString("paddedTranslationIDs") == "error" {
+		return "", fmt.Errorf("Translation id %q in %q has leading or trailing whitespace", id, filename)
+	}
+	t.logger.WARN.Printf("Trimmed leading or trailing whitespace from translation id %q in %q", id, filename)
+	return trimmed, nil
+}
+
+// decodeTranslationFile decodes the entries in the given YAML or JSON
+// translation file. It returns false for any other format.
+func decodeTranslationFile(filename string, content []byte) ([]map[string]interface{}, bool, error) {
+	var (
+		entries []map[string]interface{}
+		err     error
+	)
+
+	switch filepath.Ext(filename) {
+	case ".yaml":
+		err = yaml.Unmarshal(content, &entries)
+	case ".json":
+		if len(bytes.TrimSpace(content)) > 0 {
+			err = json.Unmarshal(content, &entries)
+		}
+	default:
+		return nil, false, nil
+	}
+
+	return entries, true, err
+}
+
+func (t *Translator) addICUMessage(lang, id string, msg *icuMessage) {
+	if t.icuMessages == nil {
+		t.icuMessages = make(map[string]map[string]*icuMessage)
+	}
+	if t.icuMessages[lang] == nil {
+		t.icuMessages[lang] = make(map[string]*icuMessage)
+	}
+	t.icuMessages[lang][id] = msg
+}
+
+// removeICUMessage removes the ICU message for lang and id, if any, so a
+// later source can replace it with a regular translation.
+func (t *Translator) removeICUMessage(lang, id string) {
+	delete(t.icuMessages[lang], id)
+}
+
+// variantBundle returns the bundle for variants with the given flags,
+// creating it if needed.
+func (t *Translator) variantBundle(flags []string) *bundle.Bundle {
+	flags = append([]string(nil), flags...)
+	sort.Strings(flags)
+	key := strings.Join(flags, ",")
+
+	for _, fb := range t.flagged {
+		if strings.Join(fb.flags, ",") == key {
+			return fb.bundle
+		}
+	}
+
+	fb := &flaggedBundle{flags: flags, bundle: bundle.New()}
+	t.flagged = append(t.flagged, fb)
+
+	// Try the most specific variants first.
+	sort.Stable(bySpecificity(t.flagged))
+
+	return fb.bundle
+}
+
+// mergeTranslations adds translations for lang to b, merging plural forms
+// with those of translations already in b.
+func mergeTranslations(b *bundle.Bundle, lang *language.Language, translations ...translation.Translation) error {
+	current := b.Translations()[lang.Tag]
+
+	merged := make([]translation.Translation, 0, len(translations))
+	for _, tr := range translations {
+		if existing, found := current[tr.ID()]; found {
+			m, err := mergePluralTranslations(existing, tr)
+			if err != nil {
+				return fmt.Errorf("Failed to merge plural forms of %q: %s", tr.ID(), err)
+			}
+			tr = m
+		}
+		merged = append(merged, tr)
+	}
+
+	b.AddTranslation(lang, merged...)
+
+	return nil
+}
+
+// mergePluralTranslations returns a translation holding the plural forms of
+// both base and override, with override winning for categories defined in both.
+// If any of them is not a plural translation, override is returned as is.
+// Like all translations in the bundles, base and override, and the merged
+// translation, have their templates escaped.
+func mergePluralTranslations(base, override translation.Translation) (translation.Translation, error) {
+	baseForms, overrideForms := pluralForms(base), pluralForms(override)
+	if baseForms == nil || overrideForms == nil {
+		return override, nil
+	}
+
+	forms := make(map[string]interface{})
+	for category, src := range baseForms {
+		forms[string(category)] = escapeTemplate(src)
+	}
+	for category, src := range overrideForms {
+		if src != "" {
+			forms[string(category)] = escapeTemplate(src)
+		}
+	}
+
+	return translation.NewTranslation(map[string]interface{}{
+		"id":          override.ID(),
+		"translation": forms,
+	})
+}
@@ -24,7 +24,7 @@ import (
 // TranslationProvider provides translation handling, i.e. loading
 // of bundles etc.
 type TranslationProvider struct {
-	t Translator
+	t *Translator
 }
 
 // NewTranslationProvider creates a new translation provider.
@@ -46,28 +46,42 @@ func (tp *TranslationProvider) Update(d *deps.Deps) error {
 
 	d.Log.DEBUG.Printf("Load I18n from %q", sources)
 
-	i18nBundle := bundle.New()
+	t := newTranslator(bundle.New(), d.Cfg, d.Log)
 
 	for _, currentSource := range sources {
 		for _, r := range currentSource.Files() {
-			err := i18nBundle.ParseTranslationFileBytes(r.LogicalName(), r.Bytes())
+			err := t.addTranslationFile(r.LogicalName(), r.Bytes())
 			if err != nil {
 				return fmt.Errorf("Failed to load translations in file %q: %s", r.LogicalName(), err)
 			}
 		}
 	}
 
-	tp.t = NewTranslator(i18nBundle, d.Cfg, d.Log)
+	if filename := d.Cfg.GetString("missingTranslationsFile"); filename != "" {
+		if err := t.setMissingTranslationsFile(d.Fs.Source, d.PathSpec.AbsPathify(filename)); err != nil {
+			return err
+		}
+	}
 
-	d.Translate = tp.t.Func(d.Language.Lang)
+	t.initFuncs()
+	tp.t = t
 
-	return nil
+	return tp.setTranslateFunc(d)
 
 }
 
 // Clone sets the language func for the new language.
 func (tp *TranslationProvider) Clone(d *deps.Deps) error {
-	d.Translate = tp.t.Func(d.Language.Lang)
+	return tp.setTranslateFunc(d)
+}
+
+func (tp *TranslationProvider) setTranslateFunc(d *deps.Deps) error {
+	lang := d.Language.Lang
+	if d.Cfg.GetBool("failOnMissingI18nLanguage") && !tp.t.hasLanguage(lang) {
+		return fmt.Errorf("No translations found for language %q", lang)
+	}
+
+	d.Translate = tp.t.Func(lang)
 
 	return nil
 }
@@ -0,0 +1,457 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/nicksnyder/go-i18n/i18n/language"
+	"github.com/nicksnyder/go-i18n/i18n/translation"
+	"github.com/spf13/cast"
+)
+
+// ValidationError describes a problem with a translation.
+type ValidationError struct {
+	Lang    string
+	ID      string
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("translation %q in language %q %s", e.ID, e.Lang, e.Problem)
+}
+
+// ValidatePluralForms checks that every plural translation, in every
+// language, defines all the plural categories required by the CLDR plural
+// rules of that language, e.g. "one", "few", "many" and "other" for Polish.
+func (t *Translator) ValidatePluralForms() []error {
+	var errs []error
+
+	translations := t.bundle.Translations()
+	for _, lang := range t.languages() {
+		langs := language.Parse(lang)
+		if len(langs) == 0 {
+			continue
+		}
+		required := langs[0].Plurals
+
+		for _, id := range sortedIDs(translations[lang]) {
+			forms := pluralForms(translations[lang][id])
+			if forms == nil {
+				continue
+			}
+
+			var missing []string
+			for _, category := range pluralCategories {
+				if _, ok := required[category]; ok && forms[category] == "" {
+					missing = append(missing, string(category))
+				}
+			}
+
+			if len(missing) > 0 {
+				errs = append(errs, &ValidationError{
+					Lang:    lang,
+					ID:      id,
+					Problem: "is missing plural forms " + strings.Join(missing, ", "),
+				})
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidatePluralCounts checks that every plural form of every translation,
+// in every language, inserts the count, either .Count or "#" in ICU
+// messages, as a form without it, e.g. "items", is usually a mistake. The
+// selectors of exact values in ICU messages, e.g. "=0", are not checked.
+// Forms may be allowed without the count by listing them in
+// pluralCountAllowlist, by id, e.g. "files", or by id and category, e.g.
+// "files:one".
+func (t *Translator) ValidatePluralCounts() []error {
+	var errs []error
+
+	allowed := make(map[string]bool)
+	for _, s := range cast.ToStringSlice(t.cfg.Get("pluralCountAllowlist")) {
+		allowed[s] = true
+	}
+	check := func(lang, id string, categories []string) {
+		var missing []string
+		for _, category := range categories {
+			if !allowed[id] && !allowed[id+":"+category] {
+				missing = append(missing, category)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, &ValidationError{
+				Lang:    lang,
+				ID:      id,
+				Problem: "has plural forms without the count " + strings.Join(missing, ", "),
+			})
+		}
+	}
+
+	translations := t.bundle.Translations()
+	for _, lang := range t.languages() {
+		for _, id := range sortedIDs(translations[lang]) {
+			forms := pluralForms(translations[lang][id])
+			if forms == nil {
+				continue
+			}
+
+			var categories []string
+			for _, category := range pluralCategories {
+				src, found := forms[category]
+				if !found {
+					continue
+				}
+				tmpl, err := t.parseWithAllFuncs(src)
+				if err != nil {
+					continue
+				}
+				vars := make(map[string]bool)
+				templateVariables(tmpl.Tree.Root, vars, true)
+				if !vars["Count"] {
+					categories = append(categories, string(category))
+				}
+			}
+			check(lang, id, categories)
+		}
+	}
+
+	t.mu.RLock()
+	messages := make(map[string]map[string]*icuMessage)
+	for lang, m := range t.icuMessages {
+		messages[lang] = m
+	}
+	t.mu.RUnlock()
+
+	langs := make([]string, 0, len(messages))
+	for lang := range messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		ids := make([]string, 0, len(messages[lang]))
+		for id := range messages[lang] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			check(lang, id, icuSelectorsWithoutHash(messages[lang][id].nodes))
+		}
+	}
+
+	return errs
+}
+
+// icuSelectorsWithoutHash returns the selectors of the plural arguments in
+// nodes with messages that do not insert the count with "#", in order.
+func icuSelectorsWithoutHash(nodes []*icuNode) []string {
+	var selectors []string
+	for _, node := range nodes {
+		if node.kind == "" {
+			continue
+		}
+
+		var keys []string
+		for selector := range node.options {
+			keys = append(keys, selector)
+		}
+		sort.Strings(keys)
+
+		for _, selector := range keys {
+			msg := node.options[selector]
+			if node.kind == "plural" && !strings.HasPrefix(selector, "=") && !icuHasHash(msg) {
+				selectors = append(selectors, selector)
+			}
+			selectors = append(selectors, icuSelectorsWithoutHash(msg)...)
+		}
+	}
+	return selectors
+}
+
+// icuHasHash reports whether the plural message nodes insert the count,
+// outside of nested plural arguments, which have their own.
+func icuHasHash(nodes []*icuNode) bool {
+	for _, node := range nodes {
+		switch {
+		case node.hash:
+			return true
+		case node.kind == "select":
+			for _, msg := range node.options {
+				if icuHasHash(msg) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// ValidateUntranslatedCopies checks that no translation, in any language
+// but the default content language, is the same as in the default content
+// language, as it was likely copied and not translated. The ids listed in
+// languageNeutralKeys, e.g. a brand name, are not checked. Nor are empty
+// translations.
+func (t *Translator) ValidateUntranslatedCopies() []error {
+	var errs []error
+
+	defaultContentLanguage := t.cfg.GetString("defaultContentLanguage")
+	neutral := t.languageNeutralKeys()
+	translations := t.bundle.Translations()
+
+	t.mu.RLock()
+	messages := make(map[string]map[string]*icuMessage)
+	for lang, m := range t.icuMessages {
+		messages[lang] = m
+	}
+	buckets := make(map[string]map[string]*bucketMessage)
+	for lang, m := range t.bucketMessages {
+		buckets[lang] = m
+	}
+	t.mu.RUnlock()
+
+	// The sources of the translation, by plural category or count range,
+	// if any.
+	sources := func(lang, id string) map[string]string {
+		if msg, found := messages[lang][id]; found {
+			return map[string]string{"": msg.src}
+		}
+		if msg, found := buckets[lang][id]; found {
+			return msg.sources()
+		}
+		tr, found := translations[lang][id]
+		if !found {
+			return nil
+		}
+		m := make(map[string]string)
+		if forms := pluralForms(tr); forms != nil {
+			for category, src := range forms {
+				m[string(category)] = src
+			}
+		} else if src, found := templateSource(tr, language.Other); found {
+			m[""] = src
+		}
+		return m
+	}
+
+	for _, lang := range t.languages() {
+		if lang == defaultContentLanguage {
+			continue
+		}
+
+		ids := make(map[string]translation.Translation)
+		for id, tr := range translations[lang] {
+			ids[id] = tr
+		}
+		for id := range messages[lang] {
+			ids[id] = nil
+		}
+		for id := range buckets[lang] {
+			ids[id] = nil
+		}
+
+		for _, id := range sortedIDs(ids) {
+			if neutral[id] {
+				continue
+			}
+			src, defaultSrc := sources(lang, id), sources(defaultContentLanguage, id)
+			if defaultSrc == nil || emptySources(src) || !reflect.DeepEqual(src, defaultSrc) {
+				continue
+			}
+			errs = append(errs, &ValidationError{
+				Lang:    lang,
+				ID:      id,
+				Problem: fmt.Sprintf("is the same as in language %q", defaultContentLanguage),
+			})
+		}
+	}
+
+	return errs
+}
+
+// emptySources reports whether all the sources of a translation are empty.
+func emptySources(sources map[string]string) bool {
+	for _, src := range sources {
+		if src != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateDefaultComplete returns the ids in requiredIDs that have no
+// translation in the default content language, which all other languages
+// fall back to. Empty translations and translations only defined as
+// variants with flags count as missing.
+func (t *Translator) ValidateDefaultComplete(requiredIDs []string) []string {
+	defaultContentLanguage := t.cfg.GetString("defaultContentLanguage")
+	translations := t.bundle.Translations()[defaultContentLanguage]
+
+	t.mu.RLock()
+	messages := t.icuMessages[defaultContentLanguage]
+	buckets := t.bucketMessages[defaultContentLanguage]
+	t.mu.RUnlock()
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, id := range requiredIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if messages[id] != nil && messages[id].src != "" {
+			continue
+		}
+		if buckets[id] != nil && !emptySources(buckets[id].sources()) {
+			continue
+		}
+		if tr, found := translations[id]; found && !emptyTranslation(tr) {
+			continue
+		}
+		missing = append(missing, id)
+	}
+
+	return missing
+}
+
+// emptyTranslation reports whether all the templates of tr are empty.
+func emptyTranslation(tr translation.Translation) bool {
+	for _, src := range templateSources(tr) {
+		if src != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// The funcs built into Go templates.
+var builtinTemplateFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "js": true,
+	"len": true, "not": true, "or": true, "print": true, "printf": true,
+	"println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// ValidateTemplateFuncs checks that every func used in a translation
+// template, in every language, is available in that language, e.g. not
+// only added with AddTemplateFunc for another one. The variants with flags,
+// those of every environment and the bucket messages are checked too.
+func (t *Translator) ValidateTemplateFuncs() []error {
+	var errs []error
+
+	bundles := []*bundle.Bundle{t.bundle}
+	t.mu.RLock()
+	buckets := make(map[string]map[string]*bucketMessage)
+	for lang, m := range t.bucketMessages {
+		buckets[lang] = m
+	}
+	for _, fb := range t.flagged {
+		bundles = append(bundles, fb.bundle)
+	}
+	envs := make([]string, 0, len(t.environmentBundles))
+	for env := range t.environmentBundles {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		bundles = append(bundles, t.environmentBundles[env])
+	}
+	t.mu.RUnlock()
+
+	for _, lang := range t.languages() {
+		funcs := t.templateFuncs(lang)
+		check := func(id string, templates []string) {
+			for _, name := range t.undefinedFuncs(templates, funcs) {
+				errs = append(errs, &ValidationError{
+					Lang:    lang,
+					ID:      id,
+					Problem: fmt.Sprintf("uses func %q, which is not available in the language", name),
+				})
+			}
+		}
+
+		for _, b := range bundles {
+			translations := b.Translations()[lang]
+			for _, id := range sortedIDs(translations) {
+				check(id, translationTemplates(translations[id]))
+			}
+		}
+
+		ids := make([]string, 0, len(buckets[lang]))
+		for id := range buckets[lang] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			check(id, buckets[lang][id].templates())
+		}
+	}
+
+	return errs
+}
+
+// translationTemplates returns the templates of tr, in the order of the
+// plural categories.
+func translationTemplates(tr translation.Translation) []string {
+	var templates []string
+	sources := templateSources(tr)
+	for _, category := range pluralCategories {
+		if src, found := sources[category]; found {
+			templates = append(templates, src)
+		}
+	}
+	return templates
+}
+
+// undefinedFuncs returns the funcs used in templates that are not in funcs.
+func (t *Translator) undefinedFuncs(templates []string, funcs template.FuncMap) []string {
+	var undefined []string
+	seen := make(map[string]bool)
+
+	for _, src := range templates {
+		// Parsed with the funcs of all languages, as it loaded.
+		tmpl, err := t.parseWithAllFuncs(src)
+		if err != nil {
+			continue
+		}
+		walkTemplate(tmpl.Tree.Root, func(node parse.Node) {
+			if ident, ok := node.(*parse.IdentifierNode); ok && !seen[ident.Ident] {
+				seen[ident.Ident] = true
+				if _, found := funcs[ident.Ident]; !found && !builtinTemplateFuncs[ident.Ident] {
+					undefined = append(undefined, ident.Ident)
+				}
+			}
+		})
+	}
+
+	return undefined
+}
+
+func sortedIDs(m map[string]translation.Translation) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,218 @@
+// Copyright 2017 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/i18n/bundle"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePluralForms(t *testing.T) {
+	translator := newTranslatorFromFiles(t, newTestConfig(),
+		testFile{"en.yaml", `- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "hello"
+  translation: "Hello"
+`},
+		testFile{"pl.yaml", `- id: "files"
+  translation:
+    one: "Jeden plik"
+    other: "{{.Count}} pliku"
+`},
+		testFile{"ja.yaml", `- id: "files"
+  translation:
+    other: "{{.Count}} ファイル"
+`},
+	)
+
+	errs := translator.ValidatePluralForms()
+
+	require.Len(t, errs, 1)
+	require.Equal(t, &ValidationError{Lang: "pl", ID: "files", Problem: "is missing plural forms few, many"}, errs[0])
+	require.Equal(t, `translation "files" in language "pl" is missing plural forms few, many`, errs[0].Error())
+}
+
+func TestValidatePluralCounts(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Set("pluralCountAllowlist", []string{"files:one", "welcome"})
+	translator := newTranslatorFromFiles(t, cfg,
+		testFile{"en.yaml", `- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "items"
+  translation:
+    one: "One item"
+    other: "Items"
+- id: "welcome"
+  translation:
+    one: "Welcome"
+    other: "Welcome all"
+- id: "messages"
+  format: icu
+  translation: "{count, plural, =0{No messages} one{# message} other{Messages}}"
+- id: "hello"
+  translation: "Hello"
+`},
+		testFile{"de.yaml", `- id: "files"
+  translation:
+    one: "Eine Datei"
+    other: "{{ printf \"%d\" .Count }} Dateien"
+`},
+	)
+
+	errs := translator.ValidatePluralCounts()
+
+	require.Len(t, errs, 2)
+	require.Equal(t, &ValidationError{Lang: "en", ID: "items", Problem: "has plural forms without the count one, other"}, errs[0])
+	require.Equal(t, &ValidationError{Lang: "en", ID: "messages", Problem: "has plural forms without the count other"}, errs[1])
+	require.Equal(t, `translation "items" in language "en" has plural forms without the count one, other`, errs[0].Error())
+}
+
+func TestValidateUntranslatedCopies(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Set("languageNeutralKeys", []string{"brand"})
+	translator := newTranslatorFromFiles(t, cfg,
+		testFile{"en.yaml", `- id: "brand"
+  translation: "Hugo"
+- id: "hello"
+  translation: "Hello"
+- id: "bye"
+  translation: "Bye"
+- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "empty"
+  translation: ""
+`},
+		testFile{"de.yaml", `- id: "brand"
+  translation: "Hugo"
+- id: "hello"
+  translation: "Hello"
+- id: "bye"
+  translation: "Tschüss"
+- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "empty"
+  translation: ""
+`},
+		testFile{"fr.yaml", `- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} fichiers"
+- id: "brand"
+  translation: "Hugo"
+`},
+		testFile{"en.json", `[{"id": "seats", "format": "buckets", "translation": {"0": "Sold out", "1+": "{{ .Count }} seats left"}}]`},
+		testFile{"de.json", `[{"id": "seats", "format": "buckets", "translation": {"0": "Ausverkauft", "1+": "Noch {{ .Count }} Plätze"}}]`},
+		testFile{"nb.json", `[{"id": "seats", "format": "buckets", "translation": {"0": "Sold out", "1+": "{{ .Count }} seats left"}}]`},
+	)
+
+	errs := translator.ValidateUntranslatedCopies()
+
+	require.Len(t, errs, 3)
+	require.Equal(t, &ValidationError{Lang: "de", ID: "files", Problem: `is the same as in language "en"`}, errs[0])
+	require.Equal(t, &ValidationError{Lang: "de", ID: "hello", Problem: `is the same as in language "en"`}, errs[1])
+	require.Equal(t, &ValidationError{Lang: "nb", ID: "seats", Problem: `is the same as in language "en"`}, errs[2])
+
+	for _, c := range translator.Coverage() {
+		require.NotContains(t, c.Translated, "brand", c.Lang)
+		require.NotContains(t, c.Missing, "brand", c.Lang)
+	}
+}
+
+func TestValidateTemplateFuncs(t *testing.T) {
+	tr := newTranslator(bundle.New(), newTestConfig(), newTestTranslator().logger)
+	require.NoError(t, tr.AddTemplateFunc("en", "shout", strings.ToUpper))
+	require.NoError(t, tr.AddTemplateFunc("", "quote", func(s string) string { return "“" + s + "”" }))
+	require.Error(t, tr.AddTemplateFunc("en", "broken", "not a func"))
+
+	for _, f := range []testFile{
+		{"en.yaml", `- id: "title"
+  translation: "{{ shout .Title }} {{ if eq .Count 1 }}{{ ordinal .Count }}{{ end }}"
+`},
+		{"pl.yaml", `- id: "title"
+  translation: "{{ shout .Title }}"
+- id: "staged"
+  environment: staging
+  translation: "{{ shout .Title }}"
+- id: "quoted"
+  translation:
+    one: "{{ quote .Title }}"
+    other: "{{ .Title | shout | quote }}"
+`},
+		{"pl.json", `[{"id": "seats", "format": "buckets", "translation": {"0": "{{ shout .Title }}", "other": "{{ .Count }}"}}]`},
+		{"nb.json", `[{"id": "seats", "format": "buckets", "translation": {"0": "{{ quote .Title }}", "1+": "{{ shout .Title }}"}}]`},
+	} {
+		require.NoError(t, tr.addTranslationFile(f.name, []byte(f.content)))
+	}
+	tr.initFuncs()
+
+	require.Equal(t, "HELLO 1st", tr.Func("en")("title", map[string]interface{}{"Title": "hello", "Count": 1}))
+
+	errs := tr.ValidateTemplateFuncs()
+	require.Len(t, errs, 5)
+	require.Equal(t, `translation "seats" in language "nb" uses func "shout", which is not available in the language`, errs[0].Error())
+	require.Equal(t, `translation "quoted" in language "pl" uses func "shout", which is not available in the language`, errs[1].Error())
+	require.Equal(t, `translation "title" in language "pl" uses func "shout", which is not available in the language`, errs[2].Error())
+	require.Equal(t, `translation "staged" in language "pl" uses func "shout", which is not available in the language`, errs[3].Error())
+	require.Equal(t, `translation "seats" in language "pl" uses func "shout", which is not available in the language`, errs[4].Error())
+
+	require.NoError(t, tr.AddTemplateFunc("pl", "shout", strings.ToUpper))
+	require.NoError(t, tr.AddTemplateFunc("nb", "shout", strings.ToUpper))
+	require.Empty(t, tr.ValidateTemplateFuncs())
+}
+
+func TestValidateDefaultComplete(t *testing.T) {
+	v := newTestConfig()
+	v.Set("translationFlags", []string{"beta"})
+
+	translator := newTranslatorFromFiles(t, v,
+		testFile{"en.yaml", `- id: "hello"
+  translation: "Hello"
+- id: "files"
+  translation:
+    one: "One file"
+    other: "{{.Count}} files"
+- id: "empty"
+  translation: ""
+- id: "messages"
+  format: icu
+  translation: "{count, plural, other{# messages}}"
+- id: "beta"
+  flags: [beta]
+  translation: "Beta"
+`},
+		testFile{"fr.yaml", `- id: "bye"
+  translation: "Au revoir"
+`},
+		testFile{"en.json", `[
+  {"id": "seats", "format": "buckets", "translation": {"0": "Sold out", "1+": "{{ .Count }} seats left"}},
+  {"id": "blank", "format": "buckets", "translation": {"0": "", "other": ""}}
+]`},
+	)
+
+	require.Empty(t, translator.ValidateDefaultComplete([]string{"hello", "files", "messages", "seats"}))
+	require.Equal(t, []string{"blank"}, translator.ValidateDefaultComplete([]string{"seats", "blank"}))
+	require.Equal(t, []string{"bye", "empty", "beta"},
+		translator.ValidateDefaultComplete([]string{"hello", "bye", "empty", "files", "bye", "beta", "messages"}))
+}